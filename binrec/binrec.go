@@ -0,0 +1,342 @@
+// Package binrec reads and writes binary files of fixed-size records, eg. particle
+// snapshots, with no parsing. Files can be memory mapped, and exposed directly as a
+// typed slice such as []PW3D.One.
+//
+// A file starts with a short, self-describing text header :
+//
+//	NPGOREC 1
+//	endian little
+//	count 1000
+//	recsize 16
+//	field Pos float32 3 0
+//	field W float32 1 12
+//	end
+//
+// giving the byte order, the number of records, the record size, and the name, type,
+// number of elements and byte offset of each field. The records follow, starting at the
+// next multiple of 8 bytes.
+//
+// Records must be structs of fixed-size numeric fields (or arrays of them), and their
+// size must be divisible by 8, as for structvec.NewStructVec.
+package binrec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+const magic = "NPGOREC 1"
+
+// Field describes a field of a record
+type Field struct {
+	Name   string
+	Type   string // eg. float32, int64
+	Count  int    // Number of elements, > 1 for arrays
+	Offset int    // Byte offset in the record
+}
+
+// Header describes the records in a file
+type Header struct {
+	Order   binary.ByteOrder
+	Count   int64
+	RecSize int
+	Fields  []Field
+}
+
+// NativeOrder is the byte order of this machine
+var NativeOrder binary.ByteOrder = nativeOrder()
+
+func nativeOrder() binary.ByteOrder {
+	x := uint16(1)
+	if *(*byte)(unsafe.Pointer(&x)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// typeSizes are the supported field types
+var typeSizes = map[string]int{
+	"int8": 1, "uint8": 1, "int16": 2, "uint16": 2, "int32": 4, "uint32": 4,
+	"int64": 8, "uint64": 8, "float32": 4, "float64": 8,
+}
+
+// HeaderOf returns the header describing records of type rec (a struct, or a slice
+// of structs), with native byte order and no records.
+func HeaderOf(rec interface{}) (*Header, error) {
+	t := reflect.TypeOf(rec)
+	if t != nil && t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return headerOfType(t)
+}
+
+func headerOfType(t reflect.Type) (*Header, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Records must be structs, got %v", t)
+	}
+	h := &Header{Order: NativeOrder, RecSize: int(t.Size())}
+	if h.RecSize%8 != 0 {
+		return nil, errors.New("Struct size must be divisible by 8")
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		f := Field{Name: sf.Name, Count: 1, Offset: int(sf.Offset)}
+		ft := sf.Type
+		if ft.Kind() == reflect.Array {
+			f.Count = ft.Len()
+			ft = ft.Elem()
+		}
+		f.Type = ft.Kind().String()
+		if typeSizes[f.Type] == 0 {
+			return nil, fmt.Errorf("Unsupported type %s for field %s", sf.Type, sf.Name)
+		}
+		h.Fields = append(h.Fields, f)
+	}
+	return h, nil
+}
+
+// Check verifies that records of type rec have the layout described by h
+func (h *Header) Check(rec interface{}) error {
+	t := reflect.TypeOf(rec)
+	if t != nil && t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return h.checkType(t)
+}
+
+func (h *Header) checkType(t reflect.Type) error {
+	h1, err := headerOfType(t)
+	if err != nil {
+		return err
+	}
+	if h1.RecSize != h.RecSize {
+		return fmt.Errorf("Record size mismatch : %s is %d bytes, file has %d", t, h1.RecSize, h.RecSize)
+	}
+	if len(h1.Fields) != len(h.Fields) {
+		return fmt.Errorf("Field count mismatch : %s has %d, file has %d", t, len(h1.Fields), len(h.Fields))
+	}
+	for i, f := range h.Fields {
+		if h1.Fields[i] != f {
+			return fmt.Errorf("Field mismatch : %s has %+v, file has %+v", t, h1.Fields[i], f)
+		}
+	}
+	return nil
+}
+
+// dataOffset returns the offset of the records, for a header of length n
+func dataOffset(n int) int64 {
+	return int64((n + 7) / 8 * 8)
+}
+
+// encode returns the header, padded to a multiple of 8 bytes
+func (h *Header) encode() []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, magic)
+	if h.Order == binary.BigEndian {
+		fmt.Fprintln(&b, "endian big")
+	} else {
+		fmt.Fprintln(&b, "endian little")
+	}
+	fmt.Fprintln(&b, "count", h.Count)
+	fmt.Fprintln(&b, "recsize", h.RecSize)
+	for _, f := range h.Fields {
+		fmt.Fprintln(&b, "field", f.Name, f.Type, f.Count, f.Offset)
+	}
+	fmt.Fprintln(&b, "end")
+	for int64(b.Len()) < dataOffset(b.Len()) {
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// ReadHeader reads a header from br, and returns it with the offset of the records.
+// br is left at the start of the records, which can then be read from it; since br
+// buffers, the underlying reader is usually further on.
+func ReadHeader(br *bufio.Reader) (*Header, int64, error) {
+	var n int
+	line := func() (string, error) {
+		s, err := br.ReadString('\n')
+		n += len(s)
+		if err != nil {
+			return "", fmt.Errorf("Error reading header : %v", err)
+		}
+		return strings.TrimSpace(s), nil
+	}
+	s, err := line()
+	if err != nil {
+		return nil, 0, err
+	}
+	if s != magic {
+		return nil, 0, errors.New("Not a binrec file")
+	}
+	h := new(Header)
+	for {
+		if s, err = line(); err != nil {
+			return nil, 0, err
+		}
+		w := strings.Fields(s)
+		if len(w) == 0 {
+			continue
+		}
+		switch {
+		case w[0] == "end" && len(w) == 1:
+			if h.Order == nil || h.RecSize <= 0 || h.Count < 0 {
+				return nil, 0, errors.New("Incomplete header")
+			}
+			off := dataOffset(n)
+			if _, err = br.Discard(int(off) - n); err != nil {
+				return nil, 0, fmt.Errorf("Error reading header : %v", err)
+			}
+			return h, off, nil
+		case w[0] == "endian" && len(w) == 2:
+			switch w[1] {
+			case "little":
+				h.Order = binary.LittleEndian
+			case "big":
+				h.Order = binary.BigEndian
+			default:
+				return nil, 0, fmt.Errorf("Unknown byte order %s", w[1])
+			}
+		case w[0] == "count" && len(w) == 2:
+			h.Count, err = strconv.ParseInt(w[1], 10, 64)
+		case w[0] == "recsize" && len(w) == 2:
+			h.RecSize, err = strconv.Atoi(w[1])
+		case w[0] == "field" && len(w) == 5:
+			f := Field{Name: w[1], Type: w[2]}
+			if typeSizes[f.Type] == 0 {
+				return nil, 0, fmt.Errorf("Unknown type %s for field %s", f.Type, f.Name)
+			}
+			if f.Count, err = strconv.Atoi(w[3]); err == nil {
+				f.Offset, err = strconv.Atoi(w[4])
+			}
+			h.Fields = append(h.Fields, f)
+		default:
+			return nil, 0, fmt.Errorf("Unknown header line %q", s)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("Bad header line %q : %v", s, err)
+		}
+	}
+}
+
+// recordBytes returns the memory of a slice of records
+func recordBytes(v reflect.Value) []byte {
+	n := v.Len() * int(v.Type().Elem().Size())
+	if n == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(v.Pointer())), n)
+}
+
+// Write writes arr, a slice of records, to w in native byte order
+func Write(w io.Writer, arr interface{}) error {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("Write needs a slice of records, got %T", arr)
+	}
+	h, err := headerOfType(v.Type().Elem())
+	if err != nil {
+		return err
+	}
+	h.Count = int64(v.Len())
+	if _, err = w.Write(h.encode()); err != nil {
+		return err
+	}
+	_, err = w.Write(recordBytes(v))
+	return err
+}
+
+// swap reverses the byte order of every field in the records in b
+func (h *Header) swap(b []byte) {
+	for off := 0; off+h.RecSize <= len(b); off += h.RecSize {
+		for _, f := range h.Fields {
+			sz := typeSizes[f.Type]
+			for k := 0; k < f.Count; k++ {
+				e := b[off+f.Offset+k*sz : off+f.Offset+(k+1)*sz]
+				for i, j := 0, sz-1; i < j; i, j = i+1, j-1 {
+					e[i], e[j] = e[j], e[i]
+				}
+			}
+		}
+	}
+}
+
+// Read reads all the records from r into *arr, a pointer to a slice of records whose
+// layout matches the header. The records are converted to native byte order.
+func Read(r io.Reader, arr interface{}) error {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Read needs a pointer to a slice of records, got %T", arr)
+	}
+	br := bufio.NewReader(r)
+	h, _, err := ReadHeader(br)
+	if err != nil {
+		return err
+	}
+	return h.read(br, v.Elem())
+}
+
+func (h *Header) read(r io.Reader, s reflect.Value) error {
+	if err := h.checkType(s.Type().Elem()); err != nil {
+		return err
+	}
+	out := reflect.MakeSlice(s.Type(), int(h.Count), int(h.Count))
+	b := recordBytes(out)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return fmt.Errorf("Error reading %d records : %v", h.Count, err)
+	}
+	if h.Order != NativeOrder {
+		h.swap(b)
+	}
+	s.Set(out)
+	return nil
+}
+
+// WriteFile writes arr, a slice of records, to the file fn. The file is written to
+// a temporary file, which is renamed to fn on success.
+func WriteFile(fn string, arr interface{}) error {
+	f, err := os.CreateTemp(filepath.Dir(fn), "."+filepath.Base(fn)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = f.Chmod(0644)
+	if err == nil {
+		bw := bufio.NewWriter(f)
+		if err = Write(bw, arr); err == nil {
+			err = bw.Flush()
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), fn)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// ReadFile reads the records in the file fn into *arr, see Read.
+func ReadFile(fn string, arr interface{}) error {
+	f, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Read(f, arr)
+}
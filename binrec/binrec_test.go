@@ -0,0 +1,235 @@
+package binrec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Same layout as PW3D.One
+type one struct {
+	Pos [3]float32
+	W   float32
+}
+
+type mixed struct {
+	X  float64
+	ID int64
+	N  [2]int32
+}
+
+func ones(n int) []one {
+	arr := make([]one, n)
+	for i := range arr {
+		arr[i] = one{Pos: [3]float32{float32(i), float32(2 * i), float32(3 * i)}, W: 0.5 * float32(i)}
+	}
+	return arr
+}
+
+func TestHeaderOf(t *testing.T) {
+	h, err := HeaderOf([]one{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Field{{"Pos", "float32", 3, 0}, {"W", "float32", 1, 12}}
+	if h.RecSize != 16 || len(h.Fields) != 2 || h.Fields[0] != want[0] || h.Fields[1] != want[1] {
+		t.Errorf("Unexpected header %+v", h)
+	}
+
+	type odd struct{ X [3]float32 }
+	if _, err = HeaderOf(odd{}); err == nil || !strings.Contains(err.Error(), "divisible by 8") {
+		t.Errorf("Expected size error, got %v", err)
+	}
+	type str struct {
+		S string
+		X float64
+	}
+	if _, err = HeaderOf(str{}); err == nil {
+		t.Error("Expected error for string field")
+	}
+	if _, err = HeaderOf(3.0); err == nil {
+		t.Error("Expected error for non-struct")
+	}
+}
+
+func TestMmap(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "test.rec")
+	arr := ones(1000)
+	if err := WriteFile(fn, arr); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != len(arr) {
+		t.Fatalf("Expected %d records, got %d", len(arr), f.Len())
+	}
+	var out []one
+	if err = f.Slice(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(arr) {
+		t.Fatalf("Expected %d records, got %d", len(arr), len(out))
+	}
+	for i := range arr {
+		if out[i] != arr[i] {
+			t.Fatalf("Record %d : expected %v, got %v", i, arr[i], out[i])
+		}
+	}
+	// The mapping is private
+	out[1].W = -1
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var back []one
+	if err = ReadFile(fn, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back[1] != arr[1] {
+		t.Errorf("File was modified : %v", back[1])
+	}
+
+	// Mismatched layouts
+	f, err = Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	type renamed struct {
+		Pos [3]float32
+		V   float32
+	}
+	var bad1 []renamed
+	if err = f.Slice(&bad1); err == nil {
+		t.Error("Expected error for renamed field")
+	}
+	var bad2 []mixed
+	if err = f.Slice(&bad2); err == nil {
+		t.Error("Expected error for different struct")
+	}
+	if err = f.Slice(out); err == nil {
+		t.Error("Expected error for non-pointer")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "empty.rec")
+	if err := WriteFile(fn, []mixed{}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	out := []mixed{{X: 1}}
+	if err = f.Slice(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected no records, got %d", len(out))
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, ones(10)); err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(t.TempDir(), "trunc.rec")
+	if err := os.WriteFile(fn, buf.Bytes()[:buf.Len()-4], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(fn); err == nil {
+		t.Error("Expected error for truncated file")
+	}
+	var out []one
+	if err := ReadFile(fn, &out); err == nil {
+		t.Error("Expected error for truncated file")
+	}
+}
+
+// Write a file in the other byte order
+func TestSwap(t *testing.T) {
+	arr := []mixed{{1.5, -7, [2]int32{3, -4}}, {-2.25, 1 << 40, [2]int32{5, 6}}}
+	h, err := HeaderOf(arr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Count = int64(len(arr))
+	if NativeOrder == binary.LittleEndian {
+		h.Order = binary.BigEndian
+	} else {
+		h.Order = binary.LittleEndian
+	}
+	b := append([]byte{}, recordBytes(reflect.ValueOf(arr))...)
+	h.swap(b)
+	fn := filepath.Join(t.TempDir(), "swap.rec")
+	if err = os.WriteFile(fn, append(h.encode(), b...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out []mixed
+	if err = ReadFile(fn, &out); err != nil {
+		t.Fatal(err)
+	}
+	for i := range arr {
+		if out[i] != arr[i] {
+			t.Errorf("Record %d : expected %v, got %v", i, arr[i], out[i])
+		}
+	}
+
+	f, err := Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err = f.Slice(&out); err == nil {
+		t.Error("Expected error mapping file in the wrong byte order")
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	var buf bytes.Buffer
+	arr := ones(3)
+	if err := Write(&buf, arr); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(&buf)
+	h, off, err := ReadHeader(br)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Count != 3 || off%8 != 0 {
+		t.Errorf("Unexpected header %+v at offset %d", h, off)
+	}
+	// The records follow in br
+	out := make([]one, 3)
+	if err = binary.Read(br, h.Order, out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(out, arr) {
+		t.Errorf("Expected %v, got %v", arr, out)
+	}
+}
+
+func TestBadHeader(t *testing.T) {
+	for _, s := range []string{
+		"not a header\n",
+		magic + "\nendian little\ncount 1\nrecsize 16\n",
+		magic + "\nendian middle\ncount 1\nrecsize 16\nend\n",
+		magic + "\nendian little\ncount 1\nrecsize 16\nfield X complex64 1 0\nend\n",
+		magic + "\ncount 1\nrecsize 16\nend\n",
+	} {
+		if _, _, err := ReadHeader(bufio.NewReader(strings.NewReader(s))); err == nil {
+			t.Errorf("Expected error for header %q", s)
+		}
+	}
+}
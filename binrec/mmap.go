@@ -0,0 +1,90 @@
+package binrec
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"syscall"
+	"unsafe"
+)
+
+// File is a memory mapped binrec file
+type File struct {
+	Header *Header
+	mem    []byte
+	data   []byte
+}
+
+// Open memory maps the file fn. The mapping is private, so the records may be
+// modified in memory without changing the file.
+func Open(fn string) (*File, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, off, err := ReadHeader(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("%s : %v", fn, err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end := off + h.Count*int64(h.RecSize)
+	if fi.Size() < end {
+		return nil, fmt.Errorf("%s is truncated : %d bytes, expected %d", fn, fi.Size(), end)
+	}
+
+	mem, err := syscall.Mmap(int(f.Fd()), 0, int(end), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, fmt.Errorf("Error mapping %s : %v", fn, err)
+	}
+	return &File{Header: h, mem: mem, data: mem[off:end]}, nil
+}
+
+// Len returns the number of records
+func (f *File) Len() int {
+	return int(f.Header.Count)
+}
+
+// Slice sets *arr, a pointer to a slice of records, to the records in the file, without
+// copying. The layout of the records must match the header, and the file must be in
+// native byte order (use ReadFile otherwise).
+//
+// The slice is only valid until Close is called.
+func (f *File) Slice(arr interface{}) error {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Slice needs a pointer to a slice of records, got %T", arr)
+	}
+	if f.mem == nil {
+		return fmt.Errorf("File is closed")
+	}
+	t := v.Elem().Type()
+	if err := f.Header.checkType(t.Elem()); err != nil {
+		return err
+	}
+	if f.Header.Order != NativeOrder {
+		return fmt.Errorf("File is %v, machine is %v", f.Header.Order, NativeOrder)
+	}
+	n := f.Len()
+	if n == 0 {
+		v.Elem().Set(reflect.MakeSlice(t, 0, 0))
+		return nil
+	}
+	v.Elem().Set(reflect.SliceAt(t.Elem(), unsafe.Pointer(&f.data[0]), n))
+	return nil
+}
+
+// Close unmaps the file
+func (f *File) Close() error {
+	if f.mem == nil {
+		return nil
+	}
+	err := syscall.Munmap(f.mem)
+	f.mem, f.data = nil, nil
+	return err
+}
@@ -0,0 +1,143 @@
+// Package fftw3 wraps the serial FFTW3 library, for single-process transforms of Go slices.
+// For distributed transforms, see petsc/grid/fftw3.
+//
+// The arrays a plan transforms are allocated by FFTW (so they are suitably aligned for
+// SIMD), and are exposed as Go slices. They are freed with the plan. Plans can be reused
+// any number of times, either on their own arrays (Execute), or on other arrays of the
+// same size (ExecuteOn).
+//
+// FFTW transforms are unnormalized; a forward transform followed by a backward transform
+// multiplies the input by the number of elements.
+//
+// FFTW needs to be built with --enable-threads for the threads library.
+package fftw3
+
+/*
+#cgo pkg-config: fftw3
+#cgo LDFLAGS: -lfftw3_threads -lpthread -lm
+
+#include <stdlib.h>
+#include <fftw3.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// Direction is the sign of the exponent in the transform
+type Direction int
+
+const (
+	Forward  Direction = C.FFTW_FORWARD
+	Backward Direction = C.FFTW_BACKWARD
+)
+
+// Flag controls the planner. Estimate returns a plan quickly, without touching the
+// arrays. The remaining flags time candidate plans, overwriting the arrays in the process,
+// so plan before filling in the input.
+type Flag uint
+
+const (
+	Estimate   Flag = C.FFTW_ESTIMATE
+	Measure    Flag = C.FFTW_MEASURE
+	Patient    Flag = C.FFTW_PATIENT
+	Exhaustive Flag = C.FFTW_EXHAUSTIVE
+	WisdomOnly Flag = C.FFTW_WISDOM_ONLY // Only plan if wisdom is available
+)
+
+// The FFTW planner is not thread-safe, only execution is, so planning and
+// wisdom are serialized through this lock.
+var planLock sync.Mutex
+
+var errPlan = errors.New("FFTW was unable to create a plan")
+
+// InitThreads initializes the FFTW threads library. It must be called before any other
+// FFTW routine if multithreaded plans are wanted.
+func InitThreads() error {
+	planLock.Lock()
+	defer planLock.Unlock()
+	if C.fftw_init_threads() == 0 {
+		return errors.New("Unable to initialize FFTW threads")
+	}
+	return nil
+}
+
+// PlanWithNThreads sets the number of threads used by all subsequently created plans.
+func PlanWithNThreads(n int) {
+	planLock.Lock()
+	defer planLock.Unlock()
+	C.fftw_plan_with_nthreads(C.int(n))
+}
+
+// Cleanup frees all memory held by FFTW, including wisdom. All existing plans must have
+// been freed.
+func Cleanup() {
+	planLock.Lock()
+	defer planLock.Unlock()
+	C.fftw_cleanup_threads()
+}
+
+// plan holds the parts common to all plans
+type plan struct {
+	p    C.fftw_plan
+	dims []int
+	bufs []unsafe.Pointer
+}
+
+// Free destroys the plan, and frees its arrays
+func (p *plan) Free() {
+	planLock.Lock()
+	C.fftw_destroy_plan(p.p)
+	planLock.Unlock()
+	for _, b := range p.bufs {
+		C.fftw_free(b)
+	}
+	p.bufs = nil
+}
+
+// Dims returns the (real space) dimensions of the transform
+func (p *plan) Dims() []int {
+	return p.dims
+}
+
+// Execute performs the transform on the plan arrays
+func (p *plan) Execute() {
+	C.fftw_execute(p.p)
+}
+
+// Flops returns an estimate of the number of floating point operations in the plan
+func (p *plan) Flops() float64 {
+	var add, mul, fma C.double
+	C.fftw_flops(p.p, &add, &mul, &fma)
+	return float64(add + mul + 2*fma)
+}
+
+// alignedLike checks that ptr has the same SIMD alignment as the plan array b, as needed
+// by the new-array execute functions. This is fftw_alignment_of, which is missing
+// before FFTW 3.3.4.
+func alignedLike(ptr unsafe.Pointer, b unsafe.Pointer) bool {
+	return uintptr(ptr)%16 == uintptr(b)%16
+}
+
+func checkDims(dims []int) (int, []C.int, error) {
+	if len(dims) == 0 {
+		return 0, nil, errors.New("Need at least one dimension")
+	}
+	n := 1
+	cdims := make([]C.int, len(dims))
+	for i, d := range dims {
+		if d < 1 {
+			return 0, nil, errors.New("Dimensions must be positive")
+		}
+		n *= d
+		cdims[i] = C.int(d)
+	}
+	return n, cdims, nil
+}
+
+func alloc(n int) unsafe.Pointer {
+	return unsafe.Pointer(C.fftw_malloc(C.size_t(n)))
+}
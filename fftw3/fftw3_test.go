@@ -0,0 +1,204 @@
+package fftw3
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func eqComplex(a, b complex128, msg string, t *testing.T) {
+	if cmplx.Abs(a-b) > 1.e-10*(1+cmplx.Abs(a)) {
+		t.Errorf("%s : expected %v, got %v", msg, a, b)
+	}
+}
+
+// dft is a brute force 1D transform
+func dft(in []complex128, sign float64) []complex128 {
+	n := len(in)
+	out := make([]complex128, n)
+	for k := range out {
+		for j, x := range in {
+			out[k] += x * cmplx.Exp(complex(0, sign*2*math.Pi*float64(j*k)/float64(n)))
+		}
+	}
+	return out
+}
+
+func TestComplex1D(t *testing.T) {
+	n := 12
+	p, err := NewComplex1D(n, Forward, Measure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+
+	// Reuse the plan on several inputs
+	for trial := 0; trial < 3; trial++ {
+		for i := range p.In {
+			p.In[i] = complex(math.Sin(float64(i*(trial+1))), float64(i%3))
+		}
+		expected := dft(p.In, -1)
+		p.Execute()
+		for i := range expected {
+			eqComplex(expected[i], p.Out[i], "Forward", t)
+		}
+	}
+
+	// Round trip with an in-place backward plan
+	q, err := NewComplex([]int{n}, Backward, Estimate, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Free()
+	copy(q.In, p.Out)
+	q.Execute()
+	for i := range p.In {
+		eqComplex(p.In[i]*complex(float64(n), 0), q.Out[i], "Round trip", t)
+	}
+}
+
+func TestComplex3D(t *testing.T) {
+	p, err := NewComplex3D(4, 6, 8, Forward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	// A single plane wave
+	k := [3]int{1, 2, 3}
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 6; j++ {
+			for l := 0; l < 8; l++ {
+				ph := 2 * math.Pi * (float64(k[0]*i)/4 + float64(k[1]*j)/6 + float64(k[2]*l)/8)
+				p.In[(i*6+j)*8+l] = cmplx.Exp(complex(0, ph))
+			}
+		}
+	}
+	p.Execute()
+	for i, v := range p.Out {
+		expected := complex(0, 0)
+		if i == (k[0]*6+k[1])*8+k[2] {
+			expected = complex(4*6*8, 0)
+		}
+		eqComplex(expected, v, "Plane wave", t)
+	}
+}
+
+func TestReal2D(t *testing.T) {
+	n0, n1 := 6, 10
+	f, err := NewReal2D(n0, n1, Forward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Free()
+	b, err := NewReal2D(n0, n1, Backward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Free()
+	if len(f.Complex) != n0*(n1/2+1) {
+		t.Fatalf("Incorrect complex size : %d", len(f.Complex))
+	}
+	cdims := ComplexDims(f.Dims())
+	if cdims[0] != n0 || cdims[1] != n1/2+1 {
+		t.Errorf("Incorrect complex dimensions : %v", cdims)
+	}
+
+	for i := range f.Real {
+		f.Real[i] = math.Cos(float64(i)) + float64(i%7)
+	}
+	f.Execute()
+
+	// Check the first row against the brute force transform
+	in := make([]complex128, n0)
+	for i := range in {
+		for j := 0; j < n1; j++ {
+			in[i] += complex(f.Real[i*n1+j], 0)
+		}
+	}
+	expected := dft(in, -1)
+	for i := range expected {
+		eqComplex(expected[i], f.Complex[i*cdims[1]], "r2c", t)
+	}
+
+	copy(b.Complex, f.Complex)
+	b.Execute()
+	for i := range f.Real {
+		if math.Abs(b.Real[i]-float64(n0*n1)*f.Real[i]) > 1.e-9 {
+			t.Errorf("Round trip : expected %f, got %f", float64(n0*n1)*f.Real[i], b.Real[i])
+		}
+	}
+}
+
+func TestExecuteOn(t *testing.T) {
+	p, err := NewReal1D(16, Forward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	q, err := NewReal1D(16, Forward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Free()
+	for i := range q.Real {
+		q.Real[i] = float64(i * i)
+	}
+	copy(p.Real, q.Real)
+	p.Execute()
+	if err = p.ExecuteOn(q.Real, q.Complex); err != nil {
+		t.Fatal(err)
+	}
+	for i := range p.Complex {
+		eqComplex(p.Complex[i], q.Complex[i], "ExecuteOn", t)
+	}
+	if err = p.ExecuteOn(q.Real[1:], q.Complex); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestWisdom(t *testing.T) {
+	ForgetWisdom()
+	p, err := NewComplex1D(32, Forward, Measure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Free()
+	w := ExportWisdom()
+	if len(w) == 0 {
+		t.Fatal("Empty wisdom")
+	}
+
+	ForgetWisdom()
+	if _, err = NewComplex1D(32, Forward, Measure|WisdomOnly); err == nil {
+		t.Error("Expected an error without wisdom, none reported")
+	}
+	if err = ImportWisdom(w); err != nil {
+		t.Fatal(err)
+	}
+	p, err = NewComplex1D(32, Forward, Measure|WisdomOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Free()
+	if err = ImportWisdom("not wisdom"); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestThreads(t *testing.T) {
+	if err := InitThreads(); err != nil {
+		t.Fatal(err)
+	}
+	PlanWithNThreads(2)
+	defer PlanWithNThreads(1)
+	p, err := NewComplex2D(64, 64, Forward, Estimate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	p.In[0] = 1
+	p.Execute()
+	for i := range p.Out {
+		eqComplex(1, p.Out[i], "Delta function", t)
+	}
+}
@@ -0,0 +1,169 @@
+package fftw3
+
+/*
+#include <fftw3.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// ComplexPlan is a complex-to-complex transform, In -> Out. Both are stored in row-major
+// order, with the dimensions of the plan.
+type ComplexPlan struct {
+	plan
+	In, Out []complex128
+}
+
+// NewComplex plans a complex-to-complex transform with dimensions dims.
+// If inPlace is true, In and Out share the same array.
+func NewComplex(dims []int, dir Direction, flags Flag, inPlace bool) (*ComplexPlan, error) {
+	n, cdims, err := checkDims(dims)
+	if err != nil {
+		return nil, err
+	}
+	p := new(ComplexPlan)
+	p.dims = append([]int(nil), dims...)
+	in := alloc(n * 16)
+	out := in
+	p.bufs = append(p.bufs, in)
+	if !inPlace {
+		out = alloc(n * 16)
+		p.bufs = append(p.bufs, out)
+	}
+	planLock.Lock()
+	p.p = C.fftw_plan_dft(C.int(len(dims)), &cdims[0], (*C.fftw_complex)(in), (*C.fftw_complex)(out),
+		C.int(dir), C.uint(flags))
+	planLock.Unlock()
+	if p.p == nil {
+		p.bufs = nil
+		C.fftw_free(in)
+		if !inPlace {
+			C.fftw_free(out)
+		}
+		return nil, errPlan
+	}
+	p.In = unsafe.Slice((*complex128)(in), n)
+	p.Out = unsafe.Slice((*complex128)(out), n)
+	return p, nil
+}
+
+// NewComplex1D plans a 1D complex-to-complex transform of length n, out of place
+func NewComplex1D(n int, dir Direction, flags Flag) (*ComplexPlan, error) {
+	return NewComplex([]int{n}, dir, flags, false)
+}
+
+// NewComplex2D plans a 2D n0 x n1 complex-to-complex transform, out of place
+func NewComplex2D(n0, n1 int, dir Direction, flags Flag) (*ComplexPlan, error) {
+	return NewComplex([]int{n0, n1}, dir, flags, false)
+}
+
+// NewComplex3D plans a 3D n0 x n1 x n2 complex-to-complex transform, out of place
+func NewComplex3D(n0, n1, n2 int, dir Direction, flags Flag) (*ComplexPlan, error) {
+	return NewComplex([]int{n0, n1, n2}, dir, flags, false)
+}
+
+// ExecuteOn reuses the plan to transform in into out. These must be the same length as
+// In and Out, share their alignment, and be the same array iff the plan is in place.
+func (p *ComplexPlan) ExecuteOn(in, out []complex128) error {
+	if len(in) != len(p.In) || len(out) != len(p.Out) {
+		return fmt.Errorf("Incompatible dimensions in ExecuteOn: in(%d), out(%d) != %d", len(in), len(out), len(p.In))
+	}
+	inPlace := &p.In[0] == &p.Out[0]
+	if (&in[0] == &out[0]) != inPlace {
+		return fmt.Errorf("ExecuteOn: in place mismatch, plan in place = %v", inPlace)
+	}
+	if !alignedLike(unsafe.Pointer(&in[0]), unsafe.Pointer(&p.In[0])) ||
+		!alignedLike(unsafe.Pointer(&out[0]), unsafe.Pointer(&p.Out[0])) {
+		return fmt.Errorf("ExecuteOn: arrays are not aligned like the plan arrays")
+	}
+	C.fftw_execute_dft(p.p, (*C.fftw_complex)(unsafe.Pointer(&in[0])), (*C.fftw_complex)(unsafe.Pointer(&out[0])))
+	return nil
+}
+
+// RealPlan is a real-to-complex (Forward) or complex-to-real (Backward) transform.
+//
+// Real is stored in row-major order with the dimensions of the plan. Complex holds the
+// non-redundant half of the transform, and has the last dimension n/2+1.
+// A Backward transform overwrites Complex.
+type RealPlan struct {
+	plan
+	Dir     Direction
+	Real    []float64
+	Complex []complex128
+}
+
+// ComplexDims returns the dimensions of the complex array
+func ComplexDims(dims []int) []int {
+	cdims := append([]int(nil), dims...)
+	cdims[len(cdims)-1] = cdims[len(cdims)-1]/2 + 1
+	return cdims
+}
+
+// NewReal plans a real transform with (real space) dimensions dims. Forward plans
+// transform Real -> Complex, and Backward plans transform Complex -> Real.
+func NewReal(dims []int, dir Direction, flags Flag) (*RealPlan, error) {
+	n, cdims, err := checkDims(dims)
+	if err != nil {
+		return nil, err
+	}
+	nc := n / dims[len(dims)-1] * (dims[len(dims)-1]/2 + 1)
+	p := new(RealPlan)
+	p.dims = append([]int(nil), dims...)
+	p.Dir = dir
+	r := alloc(n * 8)
+	c := alloc(nc * 16)
+	planLock.Lock()
+	switch dir {
+	case Forward:
+		p.p = C.fftw_plan_dft_r2c(C.int(len(dims)), &cdims[0], (*C.double)(r), (*C.fftw_complex)(c), C.uint(flags))
+	case Backward:
+		p.p = C.fftw_plan_dft_c2r(C.int(len(dims)), &cdims[0], (*C.fftw_complex)(c), (*C.double)(r), C.uint(flags))
+	}
+	planLock.Unlock()
+	if p.p == nil {
+		C.fftw_free(r)
+		C.fftw_free(c)
+		return nil, errPlan
+	}
+	p.bufs = []unsafe.Pointer{r, c}
+	p.Real = unsafe.Slice((*float64)(r), n)
+	p.Complex = unsafe.Slice((*complex128)(c), nc)
+	return p, nil
+}
+
+// NewReal1D plans a 1D real transform of length n
+func NewReal1D(n int, dir Direction, flags Flag) (*RealPlan, error) {
+	return NewReal([]int{n}, dir, flags)
+}
+
+// NewReal2D plans a 2D n0 x n1 real transform
+func NewReal2D(n0, n1 int, dir Direction, flags Flag) (*RealPlan, error) {
+	return NewReal([]int{n0, n1}, dir, flags)
+}
+
+// NewReal3D plans a 3D n0 x n1 x n2 real transform
+func NewReal3D(n0, n1, n2 int, dir Direction, flags Flag) (*RealPlan, error) {
+	return NewReal([]int{n0, n1, n2}, dir, flags)
+}
+
+// ExecuteOn reuses the plan to transform between real and cplx, in the direction of the
+// plan. These must be the same length as Real and Complex, and share their alignment.
+func (p *RealPlan) ExecuteOn(real []float64, cplx []complex128) error {
+	if len(real) != len(p.Real) || len(cplx) != len(p.Complex) {
+		return fmt.Errorf("Incompatible dimensions in ExecuteOn: real(%d) != %d or complex(%d) != %d",
+			len(real), len(p.Real), len(cplx), len(p.Complex))
+	}
+	rp, cp := unsafe.Pointer(&real[0]), unsafe.Pointer(&cplx[0])
+	if !alignedLike(rp, unsafe.Pointer(&p.Real[0])) || !alignedLike(cp, unsafe.Pointer(&p.Complex[0])) {
+		return fmt.Errorf("ExecuteOn: arrays are not aligned like the plan arrays")
+	}
+	if p.Dir == Forward {
+		C.fftw_execute_dft_r2c(p.p, (*C.double)(rp), (*C.fftw_complex)(cp))
+	} else {
+		C.fftw_execute_dft_c2r(p.p, (*C.fftw_complex)(cp), (*C.double)(rp))
+	}
+	return nil
+}
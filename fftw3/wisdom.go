@@ -0,0 +1,65 @@
+package fftw3
+
+/*
+#include <stdio.h>
+#include <stdlib.h>
+#include <fftw3.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ExportWisdom returns the accumulated wisdom as a string
+func ExportWisdom() string {
+	planLock.Lock()
+	defer planLock.Unlock()
+	s := C.fftw_export_wisdom_to_string()
+	defer C.free(unsafe.Pointer(s))
+	return C.GoString(s)
+}
+
+// ImportWisdom adds the wisdom in s to the accumulated wisdom
+func ImportWisdom(s string) error {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	planLock.Lock()
+	defer planLock.Unlock()
+	if C.fftw_import_wisdom_from_string(cs) == 0 {
+		return errors.New("Unable to import wisdom")
+	}
+	return nil
+}
+
+// ExportWisdomToFile writes the accumulated wisdom to the file fn
+func ExportWisdomToFile(fn string) error {
+	cfn := C.CString(fn)
+	defer C.free(unsafe.Pointer(cfn))
+	planLock.Lock()
+	defer planLock.Unlock()
+	if C.fftw_export_wisdom_to_filename(cfn) == 0 {
+		return errors.New("Unable to export wisdom to " + fn)
+	}
+	return nil
+}
+
+// ImportWisdomFromFile adds the wisdom in the file fn to the accumulated wisdom
+func ImportWisdomFromFile(fn string) error {
+	cfn := C.CString(fn)
+	defer C.free(unsafe.Pointer(cfn))
+	planLock.Lock()
+	defer planLock.Unlock()
+	if C.fftw_import_wisdom_from_filename(cfn) == 0 {
+		return errors.New("Unable to import wisdom from " + fn)
+	}
+	return nil
+}
+
+// ForgetWisdom discards all the accumulated wisdom
+func ForgetWisdom() {
+	planLock.Lock()
+	defer planLock.Unlock()
+	C.fftw_forget_wisdom()
+}
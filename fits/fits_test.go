@@ -0,0 +1,318 @@
+package fits
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/npadmana/npgo/lineio"
+)
+
+type galaxy struct {
+	RA, Dec float64
+	Z       float32 `fits:"REDSHIFT"`
+	ID      int64
+	Flags   uint16
+	Good    bool
+	Mag     [3]float32
+	Name    string
+	Tmp     float64 `fits:"-"`
+	private int
+}
+
+func galaxies() []galaxy {
+	return []galaxy{
+		{RA: 10.5, Dec: -3.25, Z: 0.5, ID: 1 << 40, Flags: 65535, Good: true, Mag: [3]float32{20, 21, 22}, Name: "NGC 1"},
+		{RA: 359.9, Dec: 89.1, Z: 0.01, ID: -7, Flags: 0, Good: false, Mag: [3]float32{18, 19.5, 17}, Name: "x"},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	gals := galaxies()
+	if err := Write(&buf, gals, Card{"EXTNAME", "GALAXIES"}, Card{"OMEGAM", 0.274}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len()%blockSize != 0 {
+		t.Errorf("File is not a whole number of blocks : %d", buf.Len())
+	}
+
+	tab, err := ReadTable(&buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tab.NRows != 2 || len(tab.Columns) != 8 {
+		t.Fatalf("Incorrect table : %d rows, %d columns", tab.NRows, len(tab.Columns))
+	}
+	if v, _ := tab.Header.Get("EXTNAME"); v != "GALAXIES" {
+		t.Errorf("Incorrect EXTNAME %q", v)
+	}
+	if v, _ := tab.Header.Float("OMEGAM", 0); v != 0.274 {
+		t.Errorf("Incorrect OMEGAM %v", v)
+	}
+
+	var out []galaxy
+	if err = tab.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	// "NGC 1" would split into two fields
+	if err = tab.Lines(&lineCollector{}); err == nil {
+		t.Error("An error was expected for a string containing a space")
+	}
+	for i := range gals {
+		if out[i] != gals[i] {
+			t.Errorf("Row %d : expected %+v, got %+v", i, gals[i], out[i])
+		}
+	}
+
+	// Decode into different types, and single columns
+	var out2 []struct {
+		Z   float64 `fits:"redshift"`
+		Mag [3]float64
+	}
+	if err = tab.Decode(&out2); err != nil {
+		t.Fatal(err)
+	}
+	if out2[1].Z != float64(float32(0.01)) || out2[1].Mag[1] != 19.5 {
+		t.Errorf("Incorrect conversion : %+v", out2[1])
+	}
+	ra, err := tab.Float64s("ra")
+	if err != nil || len(ra) != 2 || ra[1] != 359.9 {
+		t.Errorf("Incorrect column : %v, %v", ra, err)
+	}
+
+	// Mismatches
+	if err = tab.Decode(&[]struct {
+		ID float64 `fits:"NOPE"`
+	}{}); err == nil {
+		t.Error("An error was expected for a missing column")
+	}
+	if err = tab.Decode(&[]struct{ RA int }{}); err == nil {
+		t.Error("An error was expected for a float column into an int")
+	}
+	if err = tab.Decode(&[]struct{ Mag [2]float32 }{}); err == nil {
+		t.Error("An error was expected for a repeat count mismatch")
+	}
+}
+
+func TestCards(t *testing.T) {
+	long := strings.Repeat("x", 68)
+	var buf bytes.Buffer
+	if err := Write(&buf, galaxies(), Card{"EXTNAME", long}, Card{"NOTE-1", "it's"}); err != nil {
+		t.Fatal(err)
+	}
+	tab, err := ReadTable(&buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := tab.Header.Get("EXTNAME"); v != long {
+		t.Errorf("Incorrect EXTNAME %q", v)
+	}
+	if v, _ := tab.Header.Get("NOTE-1"); v != "it's" {
+		t.Errorf("Incorrect NOTE-1 %q", v)
+	}
+
+	for _, c := range []Card{
+		{"EXTNAME", long + "x"},              // Too long
+		{"EXTNAME", strings.Repeat("'", 35)}, // Too long once quotes are escaped
+		{"EXTNAME", "tab\t"},
+		{"LONGKEYWORD", 1},
+		{"extname", "x"},
+		{"SCALE", float32(1)},
+		{"COUNT", int64(1)},
+		{"BAD", math.NaN()},
+	} {
+		buf.Reset()
+		if err := Write(&buf, galaxies(), c); err == nil {
+			t.Errorf("%+v : an error was expected", c)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%+v : %d bytes written", c, buf.Len())
+		}
+	}
+
+	// Column names from tags must also fit
+	type longName struct {
+		X float64 `fits:"A_VERY_LONG_COLUMN_NAME_THAT_DOES_NOT_FIT_IN_A_SINGLE_FITS_HEADER_CARD"`
+	}
+	if err := Write(&buf, []longName{{1}}); err == nil {
+		t.Error("An error was expected for a long column name")
+	}
+}
+
+func TestUnsigned64(t *testing.T) {
+	type row struct {
+		U uint64
+		S int64
+	}
+	var buf bytes.Buffer
+	in := []row{{1<<63 + 5, -3}, {7, 1 << 62}}
+	if err := Write(&buf, in); err != nil {
+		t.Fatal(err)
+	}
+	tab, err := ReadTable(&buf, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []row
+	if err = tab.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[0] != in[0] || out[1] != in[1] {
+		t.Errorf("Expected %+v, got %+v", in, out)
+	}
+	if err = tab.Decode(&[]struct{ U int64 }{}); err == nil {
+		t.Error("An error was expected decoding an unsigned 64 bit column into an int64")
+	}
+
+	var lines lineCollector
+	if err = tab.Lines(&lines); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0] != "9223372036854775813 -3" || lines[1] != "7 4611686018427387904" {
+		t.Errorf("Incorrect lines : %q", lines)
+	}
+}
+
+// rdzwArr is a lineio consumer
+type rdzwArr [][4]float64
+
+func (arr *rdzwArr) Add(s []byte) error {
+	var x [4]float64
+	if err := lineio.ParseToFloat64s(s, []byte{' '}, &x[0], &x[1], &x[2], &x[3]); err != nil {
+		return err
+	}
+	*arr = append(*arr, x)
+	return nil
+}
+
+// lineCollector keeps the lines it is given
+type lineCollector []string
+
+func (l *lineCollector) Add(s []byte) error {
+	*l = append(*l, string(s))
+	return nil
+}
+
+type rdzw struct {
+	RA, Dec, Z, W float64
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	data := []rdzw{{1, 2, 0.5, 1}, {3, 4, 0.6, 0.5}}
+	fn := filepath.Join(dir, "cat.fits")
+	if err := WriteFile(fn, data); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(fn)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(b)
+	zw.Close()
+	os.WriteFile(fn+".gz", gz.Bytes(), 0644)
+	os.WriteFile(filepath.Join(dir, "cat.dat"), []byte("1 2 0.5 1\n3 4 0.6 0.5\n"), 0644)
+
+	for _, f := range []string{"cat.fits", "cat.fits.gz", "cat.dat"} {
+		var arr rdzwArr
+		if err := ReadFile(filepath.Join(dir, f), &arr); err != nil {
+			t.Fatalf("%s : %v", f, err)
+		}
+		if len(arr) != 2 || arr[1] != [4]float64{3, 4, 0.6, 0.5} {
+			t.Errorf("%s : incorrect data %v", f, arr)
+		}
+	}
+	if IsFITS("cat.dat") || !IsFITS("cat.FITS") || !IsFITS("cat.fit.bz2") {
+		t.Error("IsFITS is incorrect")
+	}
+}
+
+// card makes an 80 character card
+func card(s string) string {
+	return fmt.Sprintf("%-80s", s)
+}
+
+func block(cards ...string) []byte {
+	var b bytes.Buffer
+	for _, c := range cards {
+		b.WriteString(card(c))
+	}
+	b.WriteString(card("END"))
+	for b.Len()%blockSize != 0 {
+		b.WriteByte(' ')
+	}
+	return b.Bytes()
+}
+
+// TestHandMade reads a file built by hand, with a primary image to skip and scaled columns
+func TestHandMade(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(block("SIMPLE  =                    T", "BITPIX  =                   16",
+		"NAXIS   =                    1", "NAXIS1  =                    3", "EXTEND  =                    T"))
+	buf.Write(make([]byte, blockSize)) // 3 16-bit pixels, padded
+	buf.Write(block("XTENSION= 'IMAGE   '", "BITPIX  =                  -32", "NAXIS   =                    0"))
+	buf.Write(block("XTENSION= 'BINTABLE'           / binary table", "BITPIX  =                    8",
+		"NAXIS   =                    2", "NAXIS1  =                   10", "NAXIS2  =                    2",
+		"PCOUNT  =                    0", "GCOUNT  =                    1", "TFIELDS =                    3",
+		"TTYPE1  = 'COUNT   '", "TFORM1  = 'I       '", "TZERO1  =                32768",
+		"TTYPE2  = 'FLUX    '", "TFORM2  = '1J      '", "TSCAL2  =              0.5D0", "TZERO2  =                 10.",
+		"TTYPE3  = 'NAME    '", "TFORM3  = '4A      '", "COMMENT this is a comment = 'x'"))
+	var data bytes.Buffer
+	for _, r := range []struct {
+		count uint16
+		flux  int32
+		name  string
+	}{{40000, 3, "ab"}, {1, -4, "o''k"}} {
+		binary.Write(&data, binary.BigEndian, int16(int32(r.count)-32768))
+		binary.Write(&data, binary.BigEndian, r.flux)
+		data.WriteString(fmt.Sprintf("%-4s", r.name)[:4])
+	}
+	data.Write(make([]byte, blockSize-data.Len()))
+	buf.Write(data.Bytes())
+
+	tab, err := ReadTable(bytes.NewReader(buf.Bytes()), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []struct {
+		Count uint16
+		Flux  float64
+		Name  string
+	}
+	if err = tab.Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out[0].Count != 40000 || out[1].Count != 1 {
+		t.Errorf("Incorrect unsigned column : %+v", out)
+	}
+	if math.Abs(out[0].Flux-11.5) > 1e-12 || math.Abs(out[1].Flux-8) > 1e-12 {
+		t.Errorf("Incorrect scaled column : %+v", out)
+	}
+	if out[0].Name != "ab" || out[1].Name != "o''k" {
+		t.Errorf("Incorrect string column : %+v", out)
+	}
+	if err = tab.Decode(&[]struct{ Flux int32 }{}); err == nil {
+		t.Error("An error was expected decoding a scaled column into an integer")
+	}
+
+	var lines lineCollector
+	if err = tab.Lines(&lines); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0] != "40000 11.5 ab" || lines[1] != "1 8 o''k" {
+		t.Errorf("Incorrect lines : %q", lines)
+	}
+
+	if _, err = ReadTable(bytes.NewReader(buf.Bytes()), 2); err == nil {
+		t.Error("An error was expected for a missing extension")
+	}
+	if _, err = ReadTable(bytes.NewReader(make([]byte, blockSize)), 1); err == nil {
+		t.Error("An error was expected for a non-FITS file")
+	}
+}
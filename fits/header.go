@@ -0,0 +1,153 @@
+// Package fits reads and writes FITS binary tables (BINTABLE extensions) in pure Go.
+//
+// Tables are decoded into slices of structs (or single columns), and written from them.
+// Struct fields are matched to columns by a `fits:"NAME"` tag, or by the field name
+// (case-insensitively). Supported column formats are L, B, I, J, K, E, D and A, with
+// repeat counts mapping onto Go arrays; TSCAL/TZERO are applied when reading.
+//
+// Tables can also be fed, row by row, to a lineio.LineIOType, so that consumers written
+// for text catalogs work unchanged. See Table.Lines and ReadFile.
+package fits
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	blockSize = 2880
+	cardSize  = 80
+)
+
+// Header is a FITS header. Values are stored as strings, with the quotes stripped from
+// string values.
+type Header struct {
+	Keys   []string
+	Values map[string]string
+}
+
+func newHeader() *Header {
+	return &Header{Values: make(map[string]string)}
+}
+
+// Get returns the value of key
+func (h *Header) Get(key string) (string, bool) {
+	v, ok := h.Values[key]
+	return v, ok
+}
+
+// Int returns the value of key as an integer
+func (h *Header) Int(key string) (int, error) {
+	v, ok := h.Values[key]
+	if !ok {
+		return 0, fmt.Errorf("Keyword %s not found", key)
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("Keyword %s : %v", key, err)
+	}
+	return n, nil
+}
+
+// Float returns the value of key as a float, or def if it is missing
+func (h *Header) Float(key string, def float64) (float64, error) {
+	v, ok := h.Values[key]
+	if !ok {
+		return def, nil
+	}
+	// FITS allows D exponents
+	f, err := strconv.ParseFloat(strings.Replace(v, "D", "E", 1), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Keyword %s : %v", key, err)
+	}
+	return f, nil
+}
+
+// set adds key = value to the header
+func (h *Header) set(key, value string) {
+	if _, ok := h.Values[key]; !ok {
+		h.Keys = append(h.Keys, key)
+	}
+	h.Values[key] = value
+}
+
+// parseCard parses a single 80 character card. Returns the keyword, and the value if
+// there is one.
+func parseCard(card []byte) (key, value string, hasValue bool, err error) {
+	key = strings.TrimSpace(string(card[:8]))
+	if len(card) < 10 || string(card[8:10]) != "= " {
+		return key, "", false, nil
+	}
+	v := strings.TrimLeft(string(card[10:]), " ")
+	if strings.HasPrefix(v, "'") {
+		// String, with '' as an escaped quote
+		var sb strings.Builder
+		i := 1
+		for ; i < len(v); i++ {
+			if v[i] == '\'' {
+				if i+1 < len(v) && v[i+1] == '\'' {
+					sb.WriteByte('\'')
+					i++
+					continue
+				}
+				break
+			}
+			sb.WriteByte(v[i])
+		}
+		if i == len(v) {
+			return key, "", false, fmt.Errorf("Unterminated string in card %q", card)
+		}
+		return key, strings.TrimRight(sb.String(), " "), true, nil
+	}
+	if n := strings.IndexByte(v, '/'); n != -1 {
+		v = v[:n]
+	}
+	return key, strings.TrimSpace(v), true, nil
+}
+
+// formatCard formats a card. Strings are quoted, other values are right justified
+// in column 30. Keys must be at most 8 characters of A-Z, 0-9, - and _; strings must
+// be printable ASCII, and fit in the card. Values may be strings, bools, ints or
+// (finite) float64s.
+func formatCard(key string, value interface{}) ([]byte, error) {
+	if len(key) == 0 || len(key) > 8 {
+		return nil, fmt.Errorf("Invalid keyword %q : must be 1 to 8 characters", key)
+	}
+	for _, c := range key {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return nil, fmt.Errorf("Invalid keyword %q : only A-Z, 0-9, - and _ are allowed", key)
+		}
+	}
+	var v string
+	switch x := value.(type) {
+	case string:
+		for _, c := range x {
+			if c < ' ' || c > '~' {
+				return nil, fmt.Errorf("Invalid value for %s : %q is not printable ASCII", key, x)
+			}
+		}
+		s := strings.Replace(x, "'", "''", -1)
+		for len(s) < 8 {
+			s += " "
+		}
+		v = "'" + s + "'"
+	case bool:
+		v = fmt.Sprintf("%20s", map[bool]string{true: "T", false: "F"}[x])
+	case int:
+		v = fmt.Sprintf("%20d", x)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return nil, fmt.Errorf("Invalid value for %s : %v", key, x)
+		}
+		v = fmt.Sprintf("%20s", strconv.FormatFloat(x, 'G', -1, 64))
+	default:
+		return nil, fmt.Errorf("Unsupported header value type %T for %s", value, key)
+	}
+	card := fmt.Sprintf("%-8s= %s", key, v)
+	if len(card) > cardSize {
+		return nil, fmt.Errorf("Value for %s is too long for a card : %q", key, value)
+	}
+	return []byte(fmt.Sprintf("%-80s", card)), nil
+}
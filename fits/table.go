@@ -0,0 +1,534 @@
+package fits
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/npadmana/npgo/lineio"
+)
+
+// Column is a column of a binary table
+type Column struct {
+	Name        string
+	Format      byte // One of L, B, I, J, K, E, D, A
+	Repeat      int
+	Scale, Zero float64 // TSCAL and TZERO
+	offset      int     // Offset of the column in a row
+}
+
+// elemSize returns the size of a single element
+func elemSize(format byte) int {
+	switch format {
+	case 'L', 'B', 'A':
+		return 1
+	case 'I':
+		return 2
+	case 'J', 'E':
+		return 4
+	case 'K', 'D':
+		return 8
+	}
+	return 0
+}
+
+// parseTForm parses a TFORM value, eg. 3E
+func parseTForm(s string) (int, byte, error) {
+	s = strings.TrimSpace(s)
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == len(s) {
+		return 0, 0, fmt.Errorf("Invalid TFORM %q", s)
+	}
+	repeat := 1
+	if i > 0 {
+		repeat, _ = strconv.Atoi(s[:i])
+	}
+	if elemSize(s[i]) == 0 {
+		return 0, 0, fmt.Errorf("Unsupported TFORM %q", s)
+	}
+	return repeat, s[i], nil
+}
+
+func (c *Column) raw(row []byte, k int) []byte {
+	n := elemSize(c.Format)
+	return row[c.offset+k*n : c.offset+(k+1)*n]
+}
+
+// rawInt returns the unscaled value of an integer column
+func (c *Column) rawInt(row []byte, k int) int64 {
+	b := c.raw(row, k)
+	switch c.Format {
+	case 'B':
+		return int64(b[0])
+	case 'I':
+		return int64(int16(binary.BigEndian.Uint16(b)))
+	case 'J':
+		return int64(int32(binary.BigEndian.Uint32(b)))
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+func (c *Column) isInt() bool {
+	return c.Format == 'B' || c.Format == 'I' || c.Format == 'J' || c.Format == 'K'
+}
+
+// float returns element k of the column as a float64
+func (c *Column) float(row []byte, k int) float64 {
+	var v float64
+	switch c.Format {
+	case 'E':
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(c.raw(row, k))))
+	case 'D':
+		v = math.Float64frombits(binary.BigEndian.Uint64(c.raw(row, k)))
+	case 'L':
+		if c.raw(row, k)[0] == 'T' {
+			v = 1
+		}
+		return v
+	default:
+		v = float64(c.rawInt(row, k))
+	}
+	return c.Zero + c.Scale*v
+}
+
+// scaled returns true if an integer column has a scale, or a non-integer zero, so its
+// values are not integers
+func (c *Column) scaled() bool {
+	return c.Scale != 1 || c.Zero != math.Trunc(c.Zero)
+}
+
+// unsigned64 returns true for columns using TZERO = 2^63 for unsigned 64 bit integers,
+// whose values don't fit in an int64
+func (c *Column) unsigned64() bool {
+	return c.Zero >= 1<<63
+}
+
+// int returns element k of an integer column. Only TZERO is applied, which must fit
+// in an int64 (see unsigned64).
+func (c *Column) int(row []byte, k int) int64 {
+	return c.rawInt(row, k) + int64(c.Zero)
+}
+
+// uint returns element k of an integer column, eg. for unsigned columns with TZERO = 2^(n-1)
+func (c *Column) uint(row []byte, k int) uint64 {
+	var zero uint64
+	if c.unsigned64() {
+		zero = uint64(c.Zero)
+	} else {
+		// Wraps negative zeros around, as for the raw value
+		zero = uint64(int64(c.Zero))
+	}
+	return uint64(c.rawInt(row, k)) + zero
+}
+
+func (c *Column) str(row []byte) string {
+	b := row[c.offset : c.offset+c.Repeat]
+	if n := bytes.IndexByte(b, 0); n != -1 {
+		b = b[:n]
+	}
+	return strings.TrimRight(string(b), " ")
+}
+
+// Table is a binary table, held in memory
+type Table struct {
+	Header  *Header
+	Columns []Column
+	NRows   int
+	rowLen  int
+	data    []byte
+}
+
+// readHeader reads a header, up to and including the END card
+func readHeader(r io.Reader) (*Header, error) {
+	h := newHeader()
+	block := make([]byte, blockSize)
+	for {
+		if _, err := io.ReadFull(r, block); err != nil {
+			return nil, err
+		}
+		for i := 0; i < blockSize; i += cardSize {
+			key, value, ok, err := parseCard(block[i : i+cardSize])
+			if err != nil {
+				return nil, err
+			}
+			if key == "END" {
+				return h, nil
+			}
+			if ok {
+				h.set(key, value)
+			}
+		}
+	}
+}
+
+// dataSize returns the size of the data following h, padded to a block
+func dataSize(h *Header) (int64, error) {
+	bitpix, err := h.Int("BITPIX")
+	if err != nil {
+		return 0, err
+	}
+	naxis, err := h.Int("NAXIS")
+	if err != nil {
+		return 0, err
+	}
+	if naxis == 0 {
+		return 0, nil
+	}
+	n := int64(1)
+	for i := 1; i <= naxis; i++ {
+		ni, err := h.Int(fmt.Sprintf("NAXIS%d", i))
+		if err != nil {
+			return 0, err
+		}
+		n *= int64(ni)
+	}
+	pcount, gcount := 0, 1
+	if _, ok := h.Get("PCOUNT"); ok {
+		if pcount, err = h.Int("PCOUNT"); err != nil {
+			return 0, err
+		}
+	}
+	if _, ok := h.Get("GCOUNT"); ok {
+		if gcount, err = h.Int("GCOUNT"); err != nil {
+			return 0, err
+		}
+	}
+	if bitpix < 0 {
+		bitpix = -bitpix
+	}
+	size := int64(bitpix/8) * int64(gcount) * (int64(pcount) + n)
+	return (size + blockSize - 1) / blockSize * blockSize, nil
+}
+
+// ReadTable reads the ext-th BINTABLE extension (counting from 1) from r. Other HDUs
+// are skipped.
+func ReadTable(r io.Reader, ext int) (*Table, error) {
+	if ext < 1 {
+		return nil, errors.New("Extension numbers start at 1")
+	}
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if v, _ := h.Get("SIMPLE"); v != "T" {
+		return nil, errors.New("Not a FITS file")
+	}
+	for n := 0; ; {
+		size, err := dataSize(h)
+		if err != nil {
+			return nil, err
+		}
+		if v, _ := h.Get("XTENSION"); v == "BINTABLE" {
+			if n++; n == ext {
+				return newTable(h, r, size)
+			}
+		}
+		if _, err = io.CopyN(io.Discard, r, size); err != nil {
+			return nil, err
+		}
+		if h, err = readHeader(r); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("BINTABLE extension %d not found", ext)
+			}
+			return nil, err
+		}
+	}
+}
+
+// ReadTableFile reads the ext-th BINTABLE extension from the file fn. Compressed
+// files are handled by lineio.Open.
+func ReadTableFile(fn string, ext int) (*Table, error) {
+	ff, err := lineio.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer ff.Close()
+	return ReadTable(ff, ext)
+}
+
+func newTable(h *Header, r io.Reader, size int64) (*Table, error) {
+	t := &Table{Header: h}
+	var err error
+	if t.rowLen, err = h.Int("NAXIS1"); err != nil {
+		return nil, err
+	}
+	if t.NRows, err = h.Int("NAXIS2"); err != nil {
+		return nil, err
+	}
+	nfields, err := h.Int("TFIELDS")
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	for i := 1; i <= nfields; i++ {
+		var c Column
+		c.Name, _ = h.Get(fmt.Sprintf("TTYPE%d", i))
+		tform, ok := h.Get(fmt.Sprintf("TFORM%d", i))
+		if !ok {
+			return nil, fmt.Errorf("TFORM%d not found", i)
+		}
+		if c.Repeat, c.Format, err = parseTForm(tform); err != nil {
+			return nil, err
+		}
+		if c.Scale, err = h.Float(fmt.Sprintf("TSCAL%d", i), 1); err != nil {
+			return nil, err
+		}
+		if c.Zero, err = h.Float(fmt.Sprintf("TZERO%d", i), 0); err != nil {
+			return nil, err
+		}
+		c.offset = offset
+		offset += c.Repeat * elemSize(c.Format)
+		t.Columns = append(t.Columns, c)
+	}
+	if offset != t.rowLen {
+		return nil, fmt.Errorf("Column widths (%d) do not add up to NAXIS1 (%d)", offset, t.rowLen)
+	}
+
+	t.data = make([]byte, size)
+	if _, err = io.ReadFull(r, t.data); err != nil {
+		return nil, err
+	}
+	t.data = t.data[:t.rowLen*t.NRows]
+	return t, nil
+}
+
+// Column returns the index of the column called name (case-insensitive), or -1
+func (t *Table) Column(name string) int {
+	for i := range t.Columns {
+		if strings.EqualFold(t.Columns[i].Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *Table) row(i int) []byte {
+	return t.data[i*t.rowLen : (i+1)*t.rowLen]
+}
+
+// Float64s returns the column called name as float64s. For columns with a repeat count,
+// the elements of each row are consecutive.
+func (t *Table) Float64s(name string) ([]float64, error) {
+	ic := t.Column(name)
+	if ic == -1 {
+		return nil, fmt.Errorf("Column %s not found", name)
+	}
+	c := &t.Columns[ic]
+	if c.Format == 'A' {
+		return nil, fmt.Errorf("Column %s is a string", name)
+	}
+	arr := make([]float64, 0, t.NRows*c.Repeat)
+	for i := 0; i < t.NRows; i++ {
+		row := t.row(i)
+		for k := 0; k < c.Repeat; k++ {
+			arr = append(arr, c.float(row, k))
+		}
+	}
+	return arr, nil
+}
+
+// Lines feeds each row of the table to arr as a line of text, with the columns (and
+// the elements of array columns) separated by spaces. Logicals are written as 1 or 0.
+// This lets consumers written for lineio read FITS tables. Since the columns are split
+// on whitespace, empty strings and strings containing whitespace are an error.
+func (t *Table) Lines(arr lineio.LineIOType) error {
+	var buf []byte
+	for i := 0; i < t.NRows; i++ {
+		row := t.row(i)
+		buf = buf[:0]
+		for ic := range t.Columns {
+			c := &t.Columns[ic]
+			if c.Format == 'A' {
+				str := c.str(row)
+				if str == "" || strings.IndexFunc(str, unicode.IsSpace) != -1 {
+					return fmt.Errorf("Row %d : column %s value %q cannot be written as a single field", i, c.Name, str)
+				}
+				buf = append(append(buf, str...), ' ')
+				continue
+			}
+			for k := 0; k < c.Repeat; k++ {
+				switch {
+				case c.isInt() && !c.scaled() && c.unsigned64():
+					buf = strconv.AppendUint(buf, c.uint(row, k), 10)
+				case c.isInt() && !c.scaled():
+					buf = strconv.AppendInt(buf, c.int(row, k), 10)
+				default:
+					buf = strconv.AppendFloat(buf, c.float(row, k), 'g', -1, 64)
+				}
+				buf = append(buf, ' ')
+			}
+		}
+		if err := arr.Add(bytes.TrimSpace(buf)); err != nil {
+			return fmt.Errorf("Row %d : %v", i, err)
+		}
+	}
+	return nil
+}
+
+// IsFITS returns true if fn looks like a FITS file, ie. has a .fits, .fit or .fts
+// extension, possibly followed by a compression extension.
+func IsFITS(fn string) bool {
+	switch filepath.Ext(fn) {
+	case ".gz", ".bz2", ".zst":
+		fn = strings.TrimSuffix(fn, filepath.Ext(fn))
+	}
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".fits", ".fit", ".fts":
+		return true
+	}
+	return false
+}
+
+// ReadFile reads a catalog into arr, either the first binary table of a FITS file (see
+// IsFITS), or a text file with lineio.Read.
+func ReadFile(fn string, arr lineio.LineIOType) error {
+	if !IsFITS(fn) {
+		return lineio.Read(fn, arr)
+	}
+	t, err := ReadTableFile(fn, 1)
+	if err != nil {
+		return err
+	}
+	return t.Lines(arr)
+}
+
+// binding maps a struct field onto a column
+type binding struct {
+	field int
+	col   *Column
+}
+
+// isIntKind returns true for integer kinds
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// isSignedKind returns true for signed integer kinds
+func isSignedKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// checkKind checks that a field of kind k can be filled from column c
+func checkKind(k reflect.Kind, c *Column) bool {
+	if isIntKind(k) {
+		return c.isInt()
+	}
+	switch k {
+	case reflect.Float32, reflect.Float64:
+		return c.Format != 'A'
+	case reflect.Bool:
+		return c.Format == 'L'
+	case reflect.String:
+		return c.Format == 'A'
+	}
+	return false
+}
+
+func set(v reflect.Value, c *Column, row []byte, k int) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(c.float(row, k))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(c.int(row, k))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(c.uint(row, k))
+	case reflect.Bool:
+		v.SetBool(c.raw(row, k)[0] == 'T')
+	case reflect.String:
+		v.SetString(c.str(row))
+	}
+}
+
+// fieldName returns the column name for a struct field, and whether it was explicitly tagged
+func fieldName(sf reflect.StructField) (string, bool) {
+	if tag := sf.Tag.Get("fits"); tag != "" {
+		return tag, true
+	}
+	return sf.Name, false
+}
+
+// Decode appends the rows of the table to *arr, which must be a pointer to a slice of
+// structs. Array fields are filled from columns with the same repeat count. Tagged fields
+// must match a column; untagged fields without a column are left zero.
+func (t *Table) Decode(arr interface{}) error {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice || v.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Decode needs a pointer to a slice of structs, got %T", arr)
+	}
+	slice := v.Elem()
+	typ := slice.Type().Elem()
+
+	var binds []binding
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		name, tagged := fieldName(sf)
+		if sf.PkgPath != "" || name == "-" {
+			continue
+		}
+		ic := t.Column(name)
+		if ic == -1 {
+			if tagged {
+				return fmt.Errorf("Column %s not found", name)
+			}
+			continue
+		}
+		c := &t.Columns[ic]
+		k, n := sf.Type.Kind(), 1
+		if k == reflect.Array {
+			k, n = sf.Type.Elem().Kind(), sf.Type.Len()
+		}
+		if k == reflect.String {
+			n = c.Repeat
+		}
+		if !checkKind(k, c) || n != c.Repeat {
+			return fmt.Errorf("Cannot decode column %s (%d%c) into field %s (%s)", c.Name, c.Repeat, c.Format, sf.Name, sf.Type)
+		}
+		if isIntKind(k) && c.scaled() {
+			return fmt.Errorf("Cannot decode scaled column %s (TSCAL=%g, TZERO=%g) into integer field %s, use a float",
+				c.Name, c.Scale, c.Zero, sf.Name)
+		}
+		if isSignedKind(k) && c.unsigned64() {
+			return fmt.Errorf("Cannot decode unsigned column %s (TZERO=%g) into signed field %s, use a uint64",
+				c.Name, c.Zero, sf.Name)
+		}
+		binds = append(binds, binding{i, c})
+	}
+
+	n0 := slice.Len()
+	slice.Set(reflect.AppendSlice(slice, reflect.MakeSlice(slice.Type(), t.NRows, t.NRows)))
+	for i := 0; i < t.NRows; i++ {
+		row := t.row(i)
+		elem := slice.Index(n0 + i)
+		for _, b := range binds {
+			f := elem.Field(b.field)
+			if f.Kind() == reflect.Array {
+				for k := 0; k < f.Len(); k++ {
+					set(f.Index(k), b.col, row, k)
+				}
+			} else {
+				set(f, b.col, row, 0)
+			}
+		}
+	}
+	return nil
+}
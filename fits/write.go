@@ -0,0 +1,275 @@
+package fits
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// Card is an extra header keyword to write, eg. {"EXTNAME", "GALAXIES"}. Keys must be at
+// most 8 characters, and values may be strings (that fit in a single card), bools, ints
+// or float64s.
+type Card struct {
+	Key   string
+	Value interface{}
+}
+
+// wcol describes how a struct field is written
+type wcol struct {
+	field  int
+	name   string
+	format byte
+	repeat int
+	kind   reflect.Kind
+	zero   uint64 // TZERO for unsigned ints
+}
+
+func writeFormat(k reflect.Kind) (byte, uint64, bool) {
+	switch k {
+	case reflect.Bool:
+		return 'L', 0, true
+	case reflect.Uint8:
+		return 'B', 0, true
+	case reflect.Int8, reflect.Int16:
+		return 'I', 0, true
+	case reflect.Uint16:
+		return 'I', 1 << 15, true
+	case reflect.Int32:
+		return 'J', 0, true
+	case reflect.Uint32:
+		return 'J', 1 << 31, true
+	case reflect.Int, reflect.Int64:
+		return 'K', 0, true
+	case reflect.Uint, reflect.Uint64:
+		return 'K', 1 << 63, true
+	case reflect.Float32:
+		return 'E', 0, true
+	case reflect.Float64:
+		return 'D', 0, true
+	case reflect.String:
+		return 'A', 0, true
+	}
+	return 0, 0, false
+}
+
+// Write writes arr, a slice of structs, to w as a FITS file with an empty primary HDU
+// and a single BINTABLE extension. The exported fields are written as columns, named
+// by their fits tags or field names; fields tagged `fits:"-"` are skipped. Strings are
+// written with the width of the longest string.
+func Write(w io.Writer, arr interface{}, cards ...Card) error {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Write needs a slice of structs, got %T", arr)
+	}
+	typ := v.Type().Elem()
+	nrows := v.Len()
+
+	var cols []wcol
+	rowLen := 0
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		name, _ := fieldName(sf)
+		if sf.PkgPath != "" || name == "-" {
+			continue
+		}
+		c := wcol{field: i, name: name, repeat: 1, kind: sf.Type.Kind()}
+		if c.kind == reflect.Array {
+			c.kind, c.repeat = sf.Type.Elem().Kind(), sf.Type.Len()
+		}
+		var ok bool
+		if c.format, c.zero, ok = writeFormat(c.kind); !ok || (c.format == 'A' && sf.Type.Kind() == reflect.Array) {
+			return fmt.Errorf("Unsupported type %s for field %s", sf.Type, sf.Name)
+		}
+		if c.format == 'A' {
+			c.repeat = 1
+			for j := 0; j < nrows; j++ {
+				if n := v.Index(j).Field(i).Len(); n > c.repeat {
+					c.repeat = n
+				}
+			}
+		}
+		rowLen += c.repeat * elemSize(c.format)
+		cols = append(cols, c)
+	}
+	if len(cols) == 0 {
+		return fmt.Errorf("No columns to write in %s", typ)
+	}
+
+	// Format all the cards before writing anything
+	var prim, ext cardList
+	prim.add("SIMPLE", true)
+	prim.add("BITPIX", 8)
+	prim.add("NAXIS", 0)
+	prim.add("EXTEND", true)
+	ext.add("XTENSION", "BINTABLE")
+	ext.add("BITPIX", 8)
+	ext.add("NAXIS", 2)
+	ext.add("NAXIS1", rowLen)
+	ext.add("NAXIS2", nrows)
+	ext.add("PCOUNT", 0)
+	ext.add("GCOUNT", 1)
+	ext.add("TFIELDS", len(cols))
+	for i, c := range cols {
+		ext.add(fmt.Sprintf("TTYPE%d", i+1), c.name)
+		ext.add(fmt.Sprintf("TFORM%d", i+1), fmt.Sprintf("%d%c", c.repeat, c.format))
+		if c.zero != 0 {
+			ext.add(fmt.Sprintf("TZERO%d", i+1), float64(c.zero))
+		}
+	}
+	for _, c := range cards {
+		ext.add(c.Key, c.Value)
+	}
+	if err := prim.err; err != nil {
+		return err
+	}
+	if err := ext.err; err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := writeHeader(bw, prim.cards); err != nil {
+		return err
+	}
+	if err := writeHeader(bw, ext.cards); err != nil {
+		return err
+	}
+
+	row := make([]byte, rowLen)
+	for j := 0; j < nrows; j++ {
+		elem := v.Index(j)
+		off := 0
+		for _, c := range cols {
+			f := elem.Field(c.field)
+			switch {
+			case c.format == 'A':
+				n := copy(row[off:off+c.repeat], f.String())
+				for k := off + n; k < off+c.repeat; k++ {
+					row[k] = ' '
+				}
+				off += c.repeat
+			case f.Kind() == reflect.Array:
+				for k := 0; k < c.repeat; k++ {
+					off = encode(row, off, f.Index(k), c)
+				}
+			default:
+				off = encode(row, off, f, c)
+			}
+		}
+		if _, err := bw.Write(row); err != nil {
+			return err
+		}
+	}
+	if err := pad(bw, int64(rowLen)*int64(nrows), 0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encode writes a single value into row at off, and returns the new offset
+func encode(row []byte, off int, v reflect.Value, c wcol) int {
+	b := row[off:]
+	switch c.format {
+	case 'L':
+		b[0] = 'F'
+		if v.Bool() {
+			b[0] = 'T'
+		}
+	case 'B':
+		b[0] = byte(v.Uint())
+	case 'I', 'J', 'K':
+		var raw uint64
+		if c.zero != 0 {
+			raw = v.Uint() - c.zero
+		} else {
+			raw = uint64(v.Int())
+		}
+		switch c.format {
+		case 'I':
+			binary.BigEndian.PutUint16(b, uint16(raw))
+		case 'J':
+			binary.BigEndian.PutUint32(b, uint32(raw))
+		case 'K':
+			binary.BigEndian.PutUint64(b, raw)
+		}
+	case 'E':
+		binary.BigEndian.PutUint32(b, math.Float32bits(float32(v.Float())))
+	case 'D':
+		binary.BigEndian.PutUint64(b, math.Float64bits(v.Float()))
+	}
+	return off + elemSize(c.format)
+}
+
+// cardList collects formatted cards, keeping the first error
+type cardList struct {
+	cards [][]byte
+	err   error
+}
+
+func (l *cardList) add(key string, value interface{}) {
+	if l.err != nil {
+		return
+	}
+	c, err := formatCard(key, value)
+	if err != nil {
+		l.err = err
+		return
+	}
+	l.cards = append(l.cards, c)
+}
+
+func writeHeader(w io.Writer, cards [][]byte) error {
+	for _, c := range cards {
+		if _, err := w.Write(c); err != nil {
+			return err
+		}
+	}
+	end := []byte(fmt.Sprintf("%-80s", "END"))
+	if _, err := w.Write(end); err != nil {
+		return err
+	}
+	return pad(w, int64(len(cards)+1)*cardSize, ' ')
+}
+
+// pad pads n bytes written out to a whole block
+func pad(w io.Writer, n int64, c byte) error {
+	if n%blockSize == 0 {
+		return nil
+	}
+	b := make([]byte, blockSize-n%blockSize)
+	for i := range b {
+		b[i] = c
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// WriteFile writes arr to the file fn, as for Write. The file is written to a temporary
+// file and renamed, so fn is never left half-written.
+func WriteFile(fn string, arr interface{}, cards ...Card) error {
+	f, err := os.CreateTemp(filepath.Dir(fn), "."+filepath.Base(fn)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = f.Chmod(0644)
+	if err == nil {
+		err = Write(f, arr, cards...)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), fn)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
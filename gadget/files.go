@@ -0,0 +1,92 @@
+package gadget
+
+import (
+	"fmt"
+	"os"
+)
+
+// Files returns the files of the snapshot base, and the header of the first. If base is
+// itself a file, it is returned, otherwise the number of files is read from the header of
+// base.0.
+func Files(base string) ([]string, Header, error) {
+	fn := base
+	if _, err := os.Stat(base); err != nil {
+		fn = base + ".0"
+	}
+	s, err := Open(fn)
+	if err != nil {
+		return nil, Header{}, err
+	}
+	hdr := s.Header
+	s.Close()
+	if fn == base {
+		return []string{base}, hdr, nil
+	}
+	nfiles := int(hdr.NumFiles)
+	if nfiles < 1 {
+		return nil, Header{}, fmt.Errorf("%s has NumFiles = %d", fn, nfiles)
+	}
+	fns := make([]string, nfiles)
+	for i := range fns {
+		fns[i] = fmt.Sprintf("%s.%d", base, i)
+	}
+	return fns, hdr, nil
+}
+
+// Subset returns the files read by rank out of size, eg. for MPI. The files are
+// dealt out round-robin, so each file is read by exactly one rank.
+func Subset(fns []string, rank, size int) []string {
+	var out []string
+	for i := rank; i < len(fns); i += size {
+		out = append(out, fns[i])
+	}
+	return out
+}
+
+// Snapshot is a set of open snapshot files, eg. the subset of a snapshot read by one rank
+type Snapshot []*File
+
+// OpenFiles opens each of the files fns
+func OpenFiles(fns []string) (Snapshot, error) {
+	s := make(Snapshot, 0, len(fns))
+	for _, fn := range fns {
+		f, err := Open(fn)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s = append(s, f)
+	}
+	return s, nil
+}
+
+// Close closes all the files
+func (s Snapshot) Close() error {
+	var err error
+	for _, f := range s {
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
+// Count returns the number of particles of the types in mask in all the files
+func (s Snapshot) Count(mask uint) int64 {
+	var n int64
+	for _, f := range s {
+		n += f.Count(mask)
+	}
+	return n
+}
+
+// Read reads the particles of the types in mask from each of the files in turn,
+// see File.Read.
+func (s Snapshot) Read(mask uint, chunk int, fn func(p []Particle) error) error {
+	for _, f := range s {
+		if err := f.Read(mask, chunk, fn); err != nil {
+			return fmt.Errorf("%s : %v", f.f.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+// Package gadget reads Gadget-2 snapshot files, in either the default (format 1) or
+// the block-labelled (SnapFormat=2, format 2) layout, and in either byte order.
+//
+// Snapshots may be split over several files, named base.0, base.1, ...; see Files.
+// The particles in each file can be streamed in chunks with File.Read, without holding
+// the whole file in memory.
+package gadget
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// NTypes is the number of Gadget particle types
+const NTypes = 6
+
+// AllTypes selects all particle types in a type mask. Type i is selected by 1<<i.
+const AllTypes = 1<<NTypes - 1
+
+// Header is the Gadget-2 snapshot header
+type Header struct {
+	Npart              [NTypes]uint32  // Particles of each type in this file
+	Massarr            [NTypes]float64 // Masses of each type; if 0, read from the MASS block
+	Time               float64
+	Redshift           float64
+	FlagSfr            int32
+	FlagFeedback       int32
+	NpartTotal         [NTypes]uint32 // Particles of each type in the snapshot, low 32 bits
+	FlagCooling        int32
+	NumFiles           int32
+	BoxSize            float64
+	Omega0             float64
+	OmegaLambda        float64
+	HubbleParam        float64
+	FlagStellarAge     int32
+	FlagMetals         int32
+	NpartTotalHighWord [NTypes]uint32 // High 32 bits of NpartTotal
+	FlagEntropyICs     int32
+	Fill               [60]byte
+}
+
+// headerSize is the size of the header on disk
+const headerSize = 256
+
+// Count returns the number of particles in this file of the types in mask
+func (h *Header) Count(mask uint) int64 {
+	var n int64
+	for i, n1 := range h.Npart {
+		if mask&(1<<uint(i)) != 0 {
+			n += int64(n1)
+		}
+	}
+	return n
+}
+
+// Total returns the number of particles in the snapshot of the types in mask
+func (h *Header) Total(mask uint) int64 {
+	var n int64
+	for i, n1 := range h.NpartTotal {
+		if mask&(1<<uint(i)) != 0 {
+			n += int64(n1) + int64(h.NpartTotalHighWord[i])<<32
+		}
+	}
+	return n
+}
+
+// nmass returns the number of particles in this file with entries in the MASS block
+func (h *Header) nmass() int64 {
+	var n int64
+	for i, n1 := range h.Npart {
+		if h.Massarr[i] == 0 {
+			n += int64(n1)
+		}
+	}
+	return n
+}
+
+// Particle is a single particle
+type Particle struct {
+	Type int
+	ID   uint64
+	Pos  [3]float32
+	Vel  [3]float32
+	Mass float32
+}
+
+// block is the location of a data block in the file
+type block struct {
+	off, size int64
+}
+
+// File is an open snapshot file
+type File struct {
+	Header
+	Format int              // 1 or 2
+	Order  binary.ByteOrder // Byte order of the file
+	f      *os.File
+	blocks map[string]block
+}
+
+// format1Blocks are the blocks, in order, of a format 1 file
+var format1Blocks = []string{"HEAD", "POS", "VEL", "ID", "MASS"}
+
+// Open opens the snapshot file fn, and reads its header
+func Open(fn string) (*File, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	s := &File{f: f, blocks: make(map[string]block)}
+	if err = s.scan(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s : %v", fn, err)
+	}
+	return s, nil
+}
+
+// Close closes the file
+func (s *File) Close() error {
+	return s.f.Close()
+}
+
+// scan works out the format and byte order, reads the header, and finds the blocks.
+// Only the record markers, labels and header are read; the blocks are found by seeking.
+func (s *File) scan() error {
+	var b [4]byte
+	if _, err := s.f.ReadAt(b[:], 0); err != nil {
+		return err
+	}
+	switch {
+	case binary.LittleEndian.Uint32(b[:]) == headerSize:
+		s.Format, s.Order = 1, binary.LittleEndian
+	case binary.BigEndian.Uint32(b[:]) == headerSize:
+		s.Format, s.Order = 1, binary.BigEndian
+	case binary.LittleEndian.Uint32(b[:]) == 8:
+		s.Format, s.Order = 2, binary.LittleEndian
+	case binary.BigEndian.Uint32(b[:]) == 8:
+		s.Format, s.Order = 2, binary.BigEndian
+	default:
+		return errors.New("Not a Gadget snapshot")
+	}
+
+	fi, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+	var off int64
+	// record checks the markers of the record at off, and returns the offset and size of
+	// its data, moving off to the next record.
+	record := func() (block, error) {
+		if _, err := s.f.ReadAt(b[:], off); err != nil {
+			return block{}, fmt.Errorf("Truncated record at offset %d", off)
+		}
+		bl := block{off: off + 4, size: int64(s.Order.Uint32(b[:]))}
+		if off+bl.size+8 > fi.Size() {
+			return bl, fmt.Errorf("Truncated record at offset %d", off)
+		}
+		if _, err := s.f.ReadAt(b[:], bl.off+bl.size); err != nil {
+			return bl, err
+		}
+		if int64(s.Order.Uint32(b[:])) != bl.size {
+			return bl, fmt.Errorf("Mismatched record markers at offset %d", off)
+		}
+		off += bl.size + 8
+		return bl, nil
+	}
+
+	for iblock := 0; off < fi.Size(); iblock++ {
+		var name string
+		if s.Format == 2 {
+			bl, err := record()
+			if err != nil {
+				return err
+			}
+			if bl.size != 8 {
+				return fmt.Errorf("Expected a block label at offset %d", bl.off-4)
+			}
+			var label [4]byte
+			if _, err = s.f.ReadAt(label[:], bl.off); err != nil {
+				return err
+			}
+			name = trimLabel(label[:])
+		} else {
+			if iblock >= len(format1Blocks) {
+				break
+			}
+			name = format1Blocks[iblock]
+		}
+
+		bl, err := record()
+		if err != nil {
+			return err
+		}
+		if name == "HEAD" {
+			if bl.size != headerSize {
+				return fmt.Errorf("Header is %d bytes, expected %d", bl.size, headerSize)
+			}
+			if err = binary.Read(io.NewSectionReader(s.f, bl.off, bl.size), s.Order, &s.Header); err != nil {
+				return err
+			}
+		}
+		s.blocks[name] = bl
+	}
+
+	if _, ok := s.blocks["HEAD"]; !ok {
+		return errors.New("Missing header")
+	}
+	return nil
+}
+
+func trimLabel(b []byte) string {
+	n := len(b)
+	for n > 0 && (b[n-1] == ' ' || b[n-1] == 0) {
+		n--
+	}
+	return string(b[:n])
+}
+
+// HasBlock returns true if the file has the named block, eg. "VEL"
+func (s *File) HasBlock(name string) bool {
+	_, ok := s.blocks[name]
+	return ok
+}
+
+// blockReader reads elements of a block, of n values per particle, as float32 or uint64
+type blockReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	esize int // Size of each value, 4 or 8
+	buf   [8]byte
+}
+
+// newBlockReader returns a reader for the block name, which should have nval values for
+// each of npart particles. A missing block returns nil.
+func (s *File) newBlockReader(name string, npart, nval int64) (*blockReader, error) {
+	bl, ok := s.blocks[name]
+	if !ok || npart == 0 {
+		return nil, nil
+	}
+	esize := bl.size / (npart * nval)
+	if (esize != 4 && esize != 8) || esize*npart*nval != bl.size {
+		return nil, fmt.Errorf("%s block has %d bytes, for %d particles", name, bl.size, npart)
+	}
+	return &blockReader{
+		r:     bufio.NewReader(io.NewSectionReader(s.f, bl.off, bl.size)),
+		order: s.Order,
+		esize: int(esize),
+	}, nil
+}
+
+func (b *blockReader) next() (uint64, error) {
+	if _, err := io.ReadFull(b.r, b.buf[:b.esize]); err != nil {
+		return 0, err
+	}
+	if b.esize == 4 {
+		return uint64(b.order.Uint32(b.buf[:4])), nil
+	}
+	return b.order.Uint64(b.buf[:8]), nil
+}
+
+// float reads a float, which may be stored in double precision
+func (b *blockReader) float() (float32, error) {
+	u, err := b.next()
+	if b.esize == 4 {
+		return math.Float32frombits(uint32(u)), err
+	}
+	return float32(math.Float64frombits(u)), err
+}
+
+func (b *blockReader) floats(x *[3]float32) error {
+	var err error
+	for i := range x {
+		if x[i], err = b.float(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Read reads the particles of the types in mask, calling fn with up to chunk particles at
+// a time. The slice passed to fn is reused between calls. Particles are in file order,
+// ie. sorted by type.
+//
+// Positions are required; velocities, IDs and masses are left as zero if their blocks
+// are missing.
+func (s *File) Read(mask uint, chunk int, fn func(p []Particle) error) error {
+	if chunk < 1 {
+		return fmt.Errorf("chunk must be positive, got %d", chunk)
+	}
+	ntot := s.Count(AllTypes)
+	if !s.HasBlock("POS") && ntot > 0 {
+		return errors.New("Missing POS block")
+	}
+	pos, err := s.newBlockReader("POS", ntot, 3)
+	if err != nil {
+		return err
+	}
+	vel, err := s.newBlockReader("VEL", ntot, 3)
+	if err != nil {
+		return err
+	}
+	id, err := s.newBlockReader("ID", ntot, 1)
+	if err != nil {
+		return err
+	}
+	mass, err := s.newBlockReader("MASS", s.nmass(), 1)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]Particle, 0, chunk)
+	var p Particle
+	for itype, n := range s.Npart {
+		keep := mask&(1<<uint(itype)) != 0
+		for i := uint32(0); i < n; i++ {
+			p = Particle{Type: itype, Mass: float32(s.Massarr[itype])}
+			if err = pos.floats(&p.Pos); err != nil {
+				return fmt.Errorf("Error reading POS : %v", err)
+			}
+			if vel != nil {
+				if err = vel.floats(&p.Vel); err != nil {
+					return fmt.Errorf("Error reading VEL : %v", err)
+				}
+			}
+			if id != nil {
+				if p.ID, err = id.next(); err != nil {
+					return fmt.Errorf("Error reading ID : %v", err)
+				}
+			}
+			if mass != nil && s.Massarr[itype] == 0 {
+				if p.Mass, err = mass.float(); err != nil {
+					return fmt.Errorf("Error reading MASS : %v", err)
+				}
+			}
+			if !keep {
+				continue
+			}
+			buf = append(buf, p)
+			if len(buf) == chunk {
+				if err = fn(buf); err != nil {
+					return err
+				}
+				buf = buf[:0]
+			}
+		}
+	}
+	if len(buf) > 0 {
+		return fn(buf)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package gadget
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// snapWriter writes test snapshots
+type snapWriter struct {
+	format int
+	order  binary.ByteOrder
+	double bool // Double precision positions and velocities
+	longID bool
+	buf    bytes.Buffer
+}
+
+func (w *snapWriter) record(name string, data []byte) {
+	if w.format == 2 {
+		var label bytes.Buffer
+		label.WriteString(fmt.Sprintf("%-4s", name))
+		binary.Write(&label, w.order, uint32(len(data)+8))
+		w.record1(label.Bytes())
+	}
+	w.record1(data)
+}
+
+func (w *snapWriter) record1(data []byte) {
+	binary.Write(&w.buf, w.order, uint32(len(data)))
+	w.buf.Write(data)
+	binary.Write(&w.buf, w.order, uint32(len(data)))
+}
+
+func (w *snapWriter) float(b *bytes.Buffer, x float32, double bool) {
+	if double {
+		binary.Write(b, w.order, float64(x))
+	} else {
+		binary.Write(b, w.order, x)
+	}
+}
+
+// write writes particles p, which must be sorted by type
+func (w *snapWriter) write(fn string, h Header, p []Particle, withVel bool) error {
+	var b bytes.Buffer
+	h.Npart = [NTypes]uint32{}
+	for _, p1 := range p {
+		h.Npart[p1.Type]++
+	}
+	binary.Write(&b, w.order, &h)
+	w.record("HEAD", b.Bytes())
+
+	b.Reset()
+	for _, p1 := range p {
+		for _, x := range p1.Pos {
+			w.float(&b, x, w.double)
+		}
+	}
+	w.record("POS", b.Bytes())
+
+	if withVel {
+		b.Reset()
+		for _, p1 := range p {
+			for _, x := range p1.Vel {
+				w.float(&b, x, w.double)
+			}
+		}
+		w.record("VEL", b.Bytes())
+	}
+
+	b.Reset()
+	for _, p1 := range p {
+		if w.longID {
+			binary.Write(&b, w.order, p1.ID)
+		} else {
+			binary.Write(&b, w.order, uint32(p1.ID))
+		}
+	}
+	w.record("ID", b.Bytes())
+
+	b.Reset()
+	for _, p1 := range p {
+		if h.Massarr[p1.Type] == 0 {
+			w.float(&b, p1.Mass, false)
+		}
+	}
+	if b.Len() > 0 {
+		w.record("MASS", b.Bytes())
+	}
+	// An extra gas block, which should be ignored
+	if w.format == 2 && h.Npart[0] > 0 {
+		w.record("U", make([]byte, 4*h.Npart[0]))
+	}
+	return os.WriteFile(fn, w.buf.Bytes(), 0644)
+}
+
+// testParticles returns particles of types 0, 1 and 4, with type 1 having a fixed mass
+func testParticles() ([]Particle, Header) {
+	var h Header
+	h.Massarr[1] = 0.25
+	h.BoxSize = 100
+	h.Redshift = 0.5
+	var p []Particle
+	for i, t := range []int{0, 0, 0, 1, 1, 1, 1, 4, 4} {
+		x := float32(i)
+		p1 := Particle{Type: t, ID: uint64(i) + 1<<33, Pos: [3]float32{x, x + 0.5, 99 - x}, Vel: [3]float32{-x, 2 * x, 3}}
+		if t == 1 {
+			p1.Mass = 0.25
+		} else {
+			p1.Mass = 0.1 * x
+		}
+		p = append(p, p1)
+	}
+	for _, p1 := range p {
+		h.NpartTotal[p1.Type]++
+	}
+	return p, h
+}
+
+func readAll(t *testing.T, fns []string, mask uint, chunk int) []Particle {
+	s, err := OpenFiles(fns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var out []Particle
+	err = s.Read(mask, chunk, func(p []Particle) error {
+		if len(p) > chunk {
+			t.Errorf("Chunk too long : %d", len(p))
+		}
+		out = append(out, p...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestFormats(t *testing.T) {
+	dir := t.TempDir()
+	p, h := testParticles()
+	for _, w := range []*snapWriter{
+		{format: 1, order: binary.LittleEndian},
+		{format: 1, order: binary.BigEndian, longID: true},
+		{format: 2, order: binary.LittleEndian, double: true},
+		{format: 2, order: binary.BigEndian, longID: true},
+	} {
+		fn := filepath.Join(dir, fmt.Sprintf("snap_%d_%v_%v_%v", w.format, w.order, w.double, w.longID))
+		if err := w.write(fn, h, p, true); err != nil {
+			t.Fatal(err)
+		}
+		f, err := Open(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Format != w.format || f.Order != w.order {
+			t.Errorf("%s : detected format %d %v", fn, f.Format, f.Order)
+		}
+		if f.BoxSize != 100 || f.Redshift != 0.5 || f.Count(AllTypes) != 9 || f.Total(1<<1) != 4 {
+			t.Errorf("%s : bad header %+v", fn, f.Header)
+		}
+		f.Close()
+
+		fns, hdr, err := Files(fn)
+		if err != nil || len(fns) != 1 || hdr != f.Header {
+			t.Fatalf("Files : %v %v", fns, err)
+		}
+		out := readAll(t, fns, AllTypes, 4)
+		if len(out) != len(p) {
+			t.Fatalf("%s : expected %d particles, got %d", fn, len(p), len(out))
+		}
+		for i := range p {
+			want := p[i]
+			if !w.longID {
+				want.ID = uint64(uint32(want.ID))
+			}
+			if out[i] != want {
+				t.Errorf("%s : particle %d expected %+v, got %+v", fn, i, want, out[i])
+			}
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "snap")
+	p, h := testParticles()
+	// No velocities, which needs a labelled format 2 file
+	w := &snapWriter{format: 2, order: binary.LittleEndian, longID: true}
+	if err := w.write(fn, h, p, false); err != nil {
+		t.Fatal(err)
+	}
+	out := readAll(t, []string{fn}, 1<<1|1<<4, 100)
+	if len(out) != 6 {
+		t.Fatalf("Expected 6 particles, got %d", len(out))
+	}
+	for i, p1 := range out {
+		want := p[i+3]
+		want.Vel = [3]float32{}
+		if p1 != want {
+			t.Errorf("Particle %d expected %+v, got %+v", i, want, p1)
+		}
+	}
+}
+
+func TestMultiFile(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "snap_005")
+	p, h := testParticles()
+	h.NumFiles = 3
+	split := [][]Particle{p[:2], p[2:5], p[5:]}
+	for i, p1 := range split {
+		w := &snapWriter{format: 2, order: binary.LittleEndian, longID: true}
+		if err := w.write(fmt.Sprintf("%s.%d", base, i), h, p1, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fns, hdr, err := Files(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fns) != 3 || hdr.NumFiles != 3 || hdr.Npart[0] != 2 {
+		t.Fatalf("Expected 3 files, got %v", fns)
+	}
+
+	// Read as if on 2 ranks
+	var all []Particle
+	var ntot int64
+	for rank := 0; rank < 2; rank++ {
+		mine := Subset(fns, rank, 2)
+		s, err := OpenFiles(mine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := s.Count(AllTypes)
+		s.Close()
+		out := readAll(t, mine, AllTypes, 2)
+		if int64(len(out)) != n {
+			t.Errorf("Rank %d : counted %d, read %d", rank, n, len(out))
+		}
+		ntot += n
+		all = append(all, out...)
+	}
+	if ntot != int64(len(p)) {
+		t.Errorf("Expected %d particles, got %d", len(p), ntot)
+	}
+	seen := make(map[uint64]bool)
+	for _, p1 := range all {
+		seen[p1.ID] = true
+	}
+	for _, p1 := range p {
+		if !seen[p1.ID] {
+			t.Errorf("Particle %d not read", p1.ID)
+		}
+	}
+}
+
+func TestBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "bad")
+	if err := os.WriteFile(fn, []byte("not a snapshot at all"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(fn); err == nil {
+		t.Error("Expected error for a bad file")
+	}
+
+	p, h := testParticles()
+	w := &snapWriter{format: 1, order: binary.LittleEndian}
+	if err := w.write(fn, h, p, true); err != nil {
+		t.Fatal(err)
+	}
+	b := w.buf.Bytes()
+	if err := os.WriteFile(fn, b[:len(b)-10], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(fn); err == nil {
+		t.Error("Expected error for a truncated file")
+	}
+
+	if _, _, err := Files(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error for a missing snapshot")
+	}
+	if _, err := OpenFiles([]string{fn}); err == nil {
+		t.Error("Expected error")
+	}
+
+	if err := os.WriteFile(fn, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := OpenFiles([]string{fn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if err := s.Read(AllTypes, 0, nil); err == nil {
+		t.Error("Expected error for chunk 0")
+	}
+}
@@ -0,0 +1,512 @@
+package gnuplot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Style is the style a series is drawn with
+type Style int
+
+const (
+	Lines Style = iota
+	Points
+	LinesPoints
+)
+
+// Series is a set of points to plot, with optional errors. Zero values of the
+// style fields leave the gnuplot defaults.
+//
+// If Z is set, the series is drawn in 3D; all the series on an Axes must then have Z,
+// and errors are not supported.
+type Series struct {
+	X, Y, Z    []float64
+	XErr, YErr []float64 // Optional errors, nil if not used
+	Title      string
+	Style      Style
+	Color      string // eg. "red" or "#ff8000"
+	LineWidth  float64
+	DashType   int
+	PointType  int
+	PointSize  float64
+}
+
+// Axis describes an axis of a plot. The range is set if Min < Max, otherwise
+// gnuplot autoscales.
+type Axis struct {
+	Label    string
+	Log      bool
+	Min, Max float64
+}
+
+// Axes is a single plot in a Figure. It shows either a set of Series, or a Heatmap.
+type Axes struct {
+	Title   string
+	X, Y, Z Axis   // Z is only used for 3D series
+	Key     string // Legend position, eg. "top left"; "off" hides it
+	Grid    bool
+	Series  []*Series
+	Heatmap *Heatmap
+}
+
+// is3D returns true if the series on the axes are 3D
+func (a *Axes) is3D() bool {
+	return len(a.Series) > 0 && a.Series[0].Z != nil
+}
+
+// Heatmap is a 2D array of values, drawn as a map (with pm3d). Z[i*NY+j] is at
+// (X0 + (i+0.5) DX, Y0 + (j+0.5) DY); NaNs are left blank.
+type Heatmap struct {
+	Z              []float64
+	NX, NY         int
+	X0, Y0, DX, DY float64
+	Color          Axis   // The colour scale
+	Colormap       string // See Colormaps, or a gnuplot palette, eg. "rgbformulae 7,5,15"
+}
+
+// Colormaps are the named colormaps for Heatmap
+var Colormaps = map[string]string{
+	"viridis": "defined (0 '#440154', 0.25 '#3b528b', 0.5 '#21918c', 0.75 '#5ec962', 1 '#fde725')",
+	"gray":    "gray",
+	"hot":     "rgbformulae 21,22,23",
+	"rainbow": "rgbformulae 33,13,10",
+	"bluered": "defined (0 '#2166ac', 0.5 '#f7f7f7', 1 '#b2182b')",
+}
+
+// palette returns the gnuplot palette for the colormap
+func (h *Heatmap) palette() string {
+	if h.Colormap == "" {
+		return Colormaps["viridis"]
+	}
+	if p, ok := Colormaps[h.Colormap]; ok {
+		return p
+	}
+	return h.Colormap
+}
+
+func (h *Heatmap) check() error {
+	if h.NX < 1 || h.NY < 1 || len(h.Z) != h.NX*h.NY {
+		return fmt.Errorf("Incompatible dimensions in Heatmap: %d x %d, len(Z)=%d", h.NX, h.NY, len(h.Z))
+	}
+	return nil
+}
+
+// writeRows writes the heatmap as x, y, z, with a blank line between rows
+func (h *Heatmap) writeRows(w io.Writer) error {
+	dx, dy := h.DX, h.DY
+	if dx == 0 {
+		dx = 1
+	}
+	if dy == 0 {
+		dy = 1
+	}
+	b := make([]byte, 0, 128)
+	for i := 0; i < h.NX; i++ {
+		x := h.X0 + (float64(i)+0.5)*dx
+		for j := 0; j < h.NY; j++ {
+			b = appendFloat(b, x)
+			b = append(b, ' ')
+			b = appendFloat(b, h.Y0+(float64(j)+0.5)*dy)
+			b = append(b, ' ')
+			b = appendFloat(b, h.Z[i*h.NY+j])
+			b = append(b, '\n')
+		}
+		b = append(b, '\n')
+		if len(b) > 32768 {
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+			b = b[:0]
+		}
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// Add adds a series to the axes, and returns it
+func (a *Axes) Add(s *Series) *Series {
+	a.Series = append(a.Series, s)
+	return s
+}
+
+// Plot adds a series of lines through x, y
+func (a *Axes) Plot(x, y []float64, title string) *Series {
+	return a.Add(&Series{X: x, Y: y, Title: title})
+}
+
+// Figure is a set of plots, laid out on a grid of Rows x Cols
+type Figure struct {
+	Title         string
+	Width, Height int // In pixels, for PDF output 100 pixels per inch
+	Rows, Cols    int
+	axes          []*Axes
+}
+
+// NewFigure returns a figure of rows x cols plots, of 800x600 pixels
+func NewFigure(rows, cols int) *Figure {
+	f := &Figure{Width: 800, Height: 600, Rows: rows, Cols: cols}
+	f.axes = make([]*Axes, rows*cols)
+	for i := range f.axes {
+		f.axes[i] = new(Axes)
+	}
+	return f
+}
+
+// Axes returns the plot in row, col, counting from the top left
+func (f *Figure) Axes(row, col int) *Axes {
+	return f.axes[row*f.Cols+col]
+}
+
+// terminal returns the gnuplot terminal for the output file fn
+func (f *Figure) terminal(fn string) (string, error) {
+	switch strings.ToLower(filepath.Ext(fn)) {
+	case ".png":
+		return fmt.Sprintf("pngcairo size %d,%d", f.Width, f.Height), nil
+	case ".pdf":
+		return fmt.Sprintf("pdfcairo size %gin,%gin", float64(f.Width)/100, float64(f.Height)/100), nil
+	case ".svg":
+		return fmt.Sprintf("svg size %d,%d", f.Width, f.Height), nil
+	}
+	return "", fmt.Errorf("Unknown plot format for %s, use .png, .pdf or .svg", fn)
+}
+
+// quote quotes s as a gnuplot string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// check verifies the lengths of the series data
+func (s *Series) check() error {
+	n := len(s.X)
+	if len(s.Y) != n {
+		return fmt.Errorf("Incompatible dimensions in Series %s: len(X)=%d, len(Y)=%d", s.Title, n, len(s.Y))
+	}
+	if s.XErr != nil && len(s.XErr) != n {
+		return fmt.Errorf("Incompatible dimensions in Series %s: len(X)=%d, len(XErr)=%d", s.Title, n, len(s.XErr))
+	}
+	if s.YErr != nil && len(s.YErr) != n {
+		return fmt.Errorf("Incompatible dimensions in Series %s: len(X)=%d, len(YErr)=%d", s.Title, n, len(s.YErr))
+	}
+	if s.Z != nil && len(s.Z) != n {
+		return fmt.Errorf("Incompatible dimensions in Series %s: len(X)=%d, len(Z)=%d", s.Title, n, len(s.Z))
+	}
+	if s.Z != nil && (s.XErr != nil || s.YErr != nil) {
+		return fmt.Errorf("Errors are not supported for 3D Series %s", s.Title)
+	}
+	return nil
+}
+
+// rowWriter writes the data for a plot
+type rowWriter interface {
+	writeRows(w io.Writer) error
+}
+
+// writeData writes the data in r as a datablock called name
+func writeData(w io.Writer, name string, r rowWriter) error {
+	if _, err := io.WriteString(w, name+" << EOD\n"); err != nil {
+		return err
+	}
+	if err := r.writeRows(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "EOD\n")
+	return err
+}
+
+// writeRows writes the series as columns of x, y, z and the errors
+func (s *Series) writeRows(w io.Writer) error {
+	b := make([]byte, 0, 128)
+	for i := range s.X {
+		b = appendFloat(b, s.X[i])
+		b = append(b, ' ')
+		b = appendFloat(b, s.Y[i])
+		if s.Z != nil {
+			b = append(b, ' ')
+			b = appendFloat(b, s.Z[i])
+		}
+		if s.XErr != nil {
+			b = append(b, ' ')
+			b = appendFloat(b, s.XErr[i])
+		}
+		if s.YErr != nil {
+			b = append(b, ' ')
+			b = appendFloat(b, s.YErr[i])
+		}
+		b = append(b, '\n')
+		if len(b) > 32768 {
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+			b = b[:0]
+		}
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func appendFloat(b []byte, x float64) []byte {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return append(b, "NaN"...)
+	}
+	return strconv.AppendFloat(b, x, 'g', -1, 64)
+}
+
+// spec returns the plot specification for the series, reading from the datablock name
+func (s *Series) spec(name string) string {
+	var b strings.Builder
+	b.WriteString(name)
+	var with string
+	switch {
+	case s.Z != nil:
+		b.WriteString(" using 1:2:3")
+	case s.XErr != nil && s.YErr != nil:
+		b.WriteString(" using 1:2:3:4")
+		with = "xyerror"
+	case s.XErr != nil:
+		b.WriteString(" using 1:2:3")
+		with = "xerror"
+	case s.YErr != nil:
+		b.WriteString(" using 1:2:3")
+		with = "yerror"
+	default:
+		b.WriteString(" using 1:2")
+	}
+	switch {
+	case with != "" && s.Style == Points:
+		with += "bars"
+	case with != "":
+		with += "lines"
+	case s.Style == Points:
+		with = "points"
+	case s.Style == LinesPoints:
+		with = "linespoints"
+	default:
+		with = "lines"
+	}
+	fmt.Fprintf(&b, " with %s", with)
+	if s.Title == "" {
+		b.WriteString(" notitle")
+	} else {
+		fmt.Fprintf(&b, " title %s", quote(s.Title))
+	}
+	if s.Color != "" {
+		fmt.Fprintf(&b, " linecolor rgb %s", quote(s.Color))
+	}
+	if s.LineWidth > 0 {
+		fmt.Fprintf(&b, " linewidth %g", s.LineWidth)
+	}
+	if s.DashType > 0 {
+		fmt.Fprintf(&b, " dashtype %d", s.DashType)
+	}
+	if s.PointType > 0 {
+		fmt.Fprintf(&b, " pointtype %d", s.PointType)
+	}
+	if s.PointSize > 0 {
+		fmt.Fprintf(&b, " pointsize %g", s.PointSize)
+	}
+	return b.String()
+}
+
+// writeAxis sets up the axis ax ("x" or "y")
+func writeAxis(w io.Writer, ax string, a Axis) {
+	fmt.Fprintf(w, "set %slabel %s\n", ax, quote(a.Label))
+	if a.Log {
+		fmt.Fprintf(w, "set logscale %s\n", ax)
+	} else {
+		fmt.Fprintf(w, "unset logscale %s\n", ax)
+	}
+	if a.Min < a.Max {
+		fmt.Fprintf(w, "set %srange [%g:%g]\n", ax, a.Min, a.Max)
+	} else {
+		fmt.Fprintf(w, "set autoscale %s\n", ax)
+	}
+}
+
+// Render writes the gnuplot commands to draw the figure into the file fn. The data
+// are included as inline datablocks.
+func (f *Figure) Render(w io.Writer, fn string) error {
+	var ndata int
+	return f.render(w, fn, func(r rowWriter) (string, error) {
+		name := fmt.Sprintf("$data%d", ndata)
+		ndata++
+		return name, writeData(w, name, r)
+	})
+}
+
+// WriteScript writes a gnuplot script to draw the figure into the file fn, which can be
+// run later with gnuplot script. The data are written to files next to the script,
+// named after it, eg. plot.gp uses plot.0.dat, plot.1.dat ... Data files and fn are
+// relative to the directory gnuplot is run in, usually the directory of the script.
+func (f *Figure) WriteScript(script, fn string) error {
+	base := strings.TrimSuffix(script, filepath.Ext(script))
+	var ndata int
+	var b bytes.Buffer
+	err := f.render(&b, fn, func(r rowWriter) (string, error) {
+		dfn := fmt.Sprintf("%s.%d.dat", base, ndata)
+		ndata++
+		var d bytes.Buffer
+		if err := r.writeRows(&d); err != nil {
+			return "", err
+		}
+		return quote(filepath.Base(dfn)), os.WriteFile(dfn, d.Bytes(), 0644)
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(script, b.Bytes(), 0644)
+}
+
+// render writes the commands to draw the figure into fn. data is called for each
+// series or heatmap in turn, and returns the name to plot it with.
+func (f *Figure) render(w io.Writer, fn string, data func(r rowWriter) (string, error)) error {
+	term, err := f.terminal(fn)
+	if err != nil {
+		return err
+	}
+	// Only reset 3D settings between plots if they're used
+	var has3D bool
+	for i, a := range f.axes {
+		switch {
+		case a.Heatmap != nil && len(a.Series) > 0:
+			return fmt.Errorf("Plot %d,%d has both series and a heatmap", i/f.Cols, i%f.Cols)
+		case a.Heatmap != nil:
+			if err = a.Heatmap.check(); err != nil {
+				return err
+			}
+			has3D = true
+			continue
+		case len(a.Series) == 0:
+			return fmt.Errorf("Plot %d,%d has no series", i/f.Cols, i%f.Cols)
+		}
+		for _, s := range a.Series {
+			if err = s.check(); err != nil {
+				return err
+			}
+			if (s.Z != nil) != a.is3D() {
+				return fmt.Errorf("Plot %d,%d mixes 2D and 3D series", i/f.Cols, i%f.Cols)
+			}
+		}
+		has3D = has3D || a.is3D()
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "set terminal %s\n", term)
+	fmt.Fprintf(&b, "set output %s\n", quote(fn))
+	if _, err = w.Write(b.Bytes()); err != nil {
+		return err
+	}
+
+	// Data
+	names := make([][]string, len(f.axes))
+	for i, a := range f.axes {
+		if a.Heatmap != nil {
+			name, err := data(a.Heatmap)
+			if err != nil {
+				return err
+			}
+			names[i] = append(names[i], name)
+		}
+		for _, s := range a.Series {
+			name, err := data(s)
+			if err != nil {
+				return err
+			}
+			names[i] = append(names[i], name)
+		}
+	}
+	// Plots
+	b.Reset()
+	multi := len(f.axes) > 1
+	if multi {
+		fmt.Fprintf(&b, "set multiplot layout %d,%d title %s\n", f.Rows, f.Cols, quote(f.Title))
+	}
+	for i, a := range f.axes {
+		title := a.Title
+		if !multi && title == "" {
+			title = f.Title
+		}
+		fmt.Fprintf(&b, "set title %s\n", quote(title))
+		writeAxis(&b, "x", a.X)
+		writeAxis(&b, "y", a.Y)
+		b.WriteString("set key default\n")
+		switch a.Key {
+		case "":
+		case "off":
+			b.WriteString("unset key\n")
+		default:
+			fmt.Fprintf(&b, "set key %s\n", a.Key)
+		}
+		if a.Grid {
+			b.WriteString("set grid\n")
+		} else {
+			b.WriteString("unset grid\n")
+		}
+		if a.Heatmap != nil {
+			h := a.Heatmap
+			b.WriteString("set pm3d map corners2color c1\n")
+			fmt.Fprintf(&b, "set palette %s\n", h.palette())
+			writeAxis(&b, "cb", h.Color)
+			fmt.Fprintf(&b, "splot %s using 1:2:3 with pm3d notitle\n", names[i][0])
+			continue
+		}
+		if has3D {
+			b.WriteString("unset pm3d\nset view 60, 30, 1, 1\n")
+		}
+		specs := make([]string, len(a.Series))
+		for j, s := range a.Series {
+			specs[j] = s.spec(names[i][j])
+		}
+		cmd := "plot"
+		if a.is3D() {
+			writeAxis(&b, "z", a.Z)
+			cmd = "splot"
+		}
+		fmt.Fprintf(&b, "%s %s\n", cmd, strings.Join(specs, ", \\\n\t"))
+	}
+	if multi {
+		b.WriteString("unset multiplot\n")
+	}
+	b.WriteString("set output\n")
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// Save draws the figure into fn, using a new gnuplot process. The format is set by
+// the extension of fn, which should be .png, .pdf or .svg.
+//
+// If NPGO_GNUPLOT selects the script backend, Save calls WriteScript instead, with the
+// script named after fn in NPGO_GNUPLOT_DIR (eg. fkp_test.png is drawn by fkp_test.gp).
+// With the none backend, Save only checks the figure.
+func (f *Figure) Save(fn string) error {
+	backend, err := DefaultBackend()
+	if err != nil {
+		return err
+	}
+	switch backend {
+	case Script:
+		script := strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn)) + ".gp"
+		return f.WriteScript(filepath.Join(scriptDir(), script), fn)
+	case None:
+		return f.Render(io.Discard, fn)
+	}
+
+	var b bytes.Buffer
+	if err = f.Render(&b, fn); err != nil {
+		return err
+	}
+	p, err := NewExec(Executable, false)
+	if err != nil {
+		return err
+	}
+	if err = p.Cmd(strings.TrimSuffix(b.String(), "\n")); err != nil {
+		p.Close()
+		return err
+	}
+	return p.Close()
+}
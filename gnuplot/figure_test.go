@@ -0,0 +1,155 @@
+package gnuplot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testFigure() *Figure {
+	f := NewFigure(2, 1)
+	f.Title = "Test"
+	ax := f.Axes(0, 0)
+	ax.X = Axis{Label: "x", Log: true}
+	ax.Y = Axis{Label: `"y"`, Min: -1, Max: 1}
+	ax.Key = "top left"
+	ax.Add(&Series{X: []float64{1, 2, 3}, Y: []float64{0.5, 0.25, 0.125}, YErr: []float64{0.1, 0.1, 0.1}, Title: "data", Style: Points, PointSize: 2, Color: "red"})
+	ax.Plot([]float64{1, 3}, []float64{0.5, 0.125}, "model").LineWidth = 3
+	ax = f.Axes(1, 0)
+	ax.Key = "off"
+	ax.Grid = true
+	ax.Add(&Series{X: []float64{0, 1}, Y: []float64{1, 2}, XErr: []float64{0.1, 0.2}, YErr: []float64{0.3, 0.4}, Style: LinesPoints})
+	return f
+}
+
+func TestRender(t *testing.T) {
+	var b bytes.Buffer
+	if err := testFigure().Render(&b, "out.pdf"); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+	for _, want := range []string{
+		"set terminal pdfcairo size 8in,6in\n",
+		"set output \"out.pdf\"\n",
+		"$data0 << EOD\n1 0.5 0.1\n2 0.25 0.1\n3 0.125 0.1\nEOD\n",
+		"$data2 << EOD\n0 1 0.1 0.3\n1 2 0.2 0.4\nEOD\n",
+		"set multiplot layout 2,1 title \"Test\"\n",
+		"set logscale x\n",
+		"set ylabel \"\\\"y\\\"\"\n",
+		"set yrange [-1:1]\n",
+		"set key top left\n",
+		"plot $data0 using 1:2:3 with yerrorbars title \"data\" linecolor rgb \"red\" pointsize 2, \\\n\t$data1 using 1:2 with lines title \"model\" linewidth 3\n",
+		"unset key\nset grid\n",
+		"plot $data2 using 1:2:3:4 with xyerrorlines notitle\n",
+		"unset multiplot\nset output\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Missing %q in\n%s", want, s)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	var b bytes.Buffer
+	if err := testFigure().Render(&b, "out.jpg"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	f := NewFigure(1, 2)
+	f.Axes(0, 0).Plot([]float64{1}, []float64{1}, "")
+	if err := f.Render(&b, "out.png"); err == nil {
+		t.Error("Expected error for empty axes")
+	}
+	f.Axes(0, 1).Add(&Series{X: []float64{1, 2}, Y: []float64{1, 2}, YErr: []float64{1}})
+	if err := f.Render(&b, "out.png"); err == nil || !strings.Contains(err.Error(), "Incompatible dimensions") {
+		t.Errorf("Expected dimension error, got %v", err)
+	}
+}
+
+func TestSave(t *testing.T) {
+	defer func(s string) { Executable = s }(Executable)
+	Executable = fake
+	log := filepath.Join(t.TempDir(), "log")
+	t.Setenv("FAKE_GNUPLOT_LOG", log)
+
+	f := NewFigure(1, 1)
+	f.Width, f.Height = 640, 480
+	f.Axes(0, 0).Plot([]float64{1, 2}, []float64{3, 4}, "a")
+	if err := f.Save("out.svg"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	f.Render(&want, "out.svg")
+	if got := strings.TrimPrefix(string(b), "args: \n"); got != want.String() {
+		t.Errorf("Expected\n%s\ngot\n%s", want.String(), got)
+	}
+
+	Executable = filepath.Join(t.TempDir(), "no-gnuplot")
+	if err = f.Save("out.svg"); err == nil {
+		t.Error("Expected error for missing gnuplot")
+	}
+}
+
+func TestWriteScript(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fig.gp")
+	if err := testFigure().WriteScript(script, "fig.png"); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(b)
+	for _, want := range []string{
+		"set terminal pngcairo size 800,600\nset output \"fig.png\"\nset multiplot",
+		"plot \"fig.0.dat\" using 1:2:3 with yerrorbars",
+		"\t\"fig.1.dat\" using 1:2 with lines",
+		"plot \"fig.2.dat\" using 1:2:3:4",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Missing %q in\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "EOD") {
+		t.Errorf("Unexpected inline data in\n%s", s)
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "fig.2.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "0 1 0.1 0.3\n1 2 0.2 0.4\n" {
+		t.Errorf("Unexpected data %q", b)
+	}
+}
+
+func TestSaveBackends(t *testing.T) {
+	dir := t.TempDir()
+	defer func(s string) { Executable = s }(Executable)
+	Executable = filepath.Join(dir, "no-gnuplot")
+
+	t.Setenv(EnvBackend, "script")
+	t.Setenv(EnvScriptDir, dir)
+	f := testFigure()
+	if err := f.Save("plots/fkp_test.png"); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []string{"fkp_test.gp", "fkp_test.0.dat", "fkp_test.1.dat", "fkp_test.2.dat"} {
+		if _, err := os.Stat(filepath.Join(dir, fn)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	t.Setenv(EnvBackend, "none")
+	if err := f.Save("fkp_test.png"); err != nil {
+		t.Error(err)
+	}
+	if err := f.Save("fkp_test.gif"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
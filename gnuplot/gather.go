@@ -0,0 +1,23 @@
+package gnuplot
+
+// Gatherer collects data from all MPI ranks onto rank 0, for the plotting helpers
+// GridSlice and ParticleSample. mpi.Gatherer implements this.
+type Gatherer interface {
+	Rank() int
+	// GatherFloat64 returns x from all ranks, concatenated in rank order, on rank 0.
+	// It is collective, and must be called on all ranks.
+	GatherFloat64(x []float64) ([]float64, error)
+}
+
+// gatherRoot collects x onto rank 0. It returns false on other ranks, which have nothing
+// to plot. A nil Gatherer is a single process.
+func gatherRoot(g Gatherer, x []float64) ([]float64, bool, error) {
+	if g == nil {
+		return x, true, nil
+	}
+	all, err := g.GatherFloat64(x)
+	if err != nil {
+		return nil, false, err
+	}
+	return all, g.Rank() == 0, nil
+}
@@ -1,46 +1,368 @@
-//Package gnuplot provides convenience wrappers for piping to gnuplot.
+// Package gnuplot provides convenience wrappers for piping to gnuplot.
+//
+// Where gnuplot isn't available (eg. on compute nodes), the environment variable
+// NPGO_GNUPLOT selects a different backend for New and Figure.Save: "script" writes
+// gnuplot scripts (to the directory NPGO_GNUPLOT_DIR, default the current directory)
+// that can be rendered later, and "none" discards all plots.
 package gnuplot
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-type Plot chan string
+// Executable is the gnuplot executable run by New
+var Executable = "gnuplot"
 
-func handleGnuplot(p Plot, pipe io.WriteCloser) {
-	var command string
-	ok := true
+// Backend is how plots are drawn
+type Backend int
 
-	for ok {
-		command, ok = <-p
-		if !ok {
-			pipe.Close()
-			continue
+const (
+	Process Backend = iota // Pipe to gnuplot
+	Script                 // Write gnuplot scripts
+	None                   // Discard plots
+)
+
+// Environment variables that select the backend, and the directory for scripts
+const (
+	EnvBackend   = "NPGO_GNUPLOT"
+	EnvScriptDir = "NPGO_GNUPLOT_DIR"
+)
+
+// DefaultBackend returns the backend set by NPGO_GNUPLOT, which may be "gnuplot" (or
+// empty), "script" or "none".
+func DefaultBackend() (Backend, error) {
+	switch b := os.Getenv(EnvBackend); strings.ToLower(b) {
+	case "", "gnuplot":
+		return Process, nil
+	case "script":
+		return Script, nil
+	case "none":
+		return None, nil
+	default:
+		return Process, fmt.Errorf("Unknown %s backend %q, use gnuplot, script or none", EnvBackend, b)
+	}
+}
+
+// scriptDir returns the directory for scripts
+func scriptDir() string {
+	if dir := os.Getenv(EnvScriptDir); dir != "" {
+		return dir
+	}
+	return "."
+}
+
+// nscript counts the scripts written by New
+var nscript int64
+
+// DefaultTimeout is how long Sync and Close wait for gnuplot, if Plot.Timeout is not set
+var DefaultTimeout = 30 * time.Second
+
+// syncPrefix marks the lines printed by Sync
+const syncPrefix = "npgo-gnuplot-sync-"
+
+// Error holds the messages gnuplot wrote to stderr
+type Error struct {
+	Messages []string
+}
+
+func (e *Error) Error() string {
+	return "gnuplot : " + strings.Join(e.Messages, "\n")
+}
+
+// Plot is a running gnuplot process. Commands are sent with Cmd; anything gnuplot writes
+// to stderr (errors, warnings and the output of print) is collected, and returned by
+// Sync, Err and Close.
+//
+// A Plot may instead write the commands to a script (see NewScript), or discard them
+// (see NewNone). Neither checks the commands.
+//
+// A Plot should only be used from one goroutine at a time.
+type Plot struct {
+	Timeout time.Duration // Timeout for Sync and Close
+
+	cmd     *exec.Cmd // nil if not running gnuplot
+	stdin   io.WriteCloser
+	stderr  *errWriter
+	done    chan struct{} // Closed when gnuplot exits
+	waitErr error
+	err     error // First error writing to gnuplot
+	nsync   int
+	closed  bool
+}
+
+// errWriter collects the lines gnuplot writes to stderr
+type errWriter struct {
+	mu   sync.Mutex
+	line []byte // Incomplete line
+	msgs []string
+	sync chan string // Sync markers
+}
+
+func (w *errWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.line = append(w.line, b...)
+	for {
+		i := bytes.IndexByte(w.line, '\n')
+		if i < 0 {
+			break
+		}
+		s := strings.TrimRight(string(w.line[:i]), "\r")
+		w.line = w.line[i+1:]
+		switch {
+		case strings.HasPrefix(s, syncPrefix):
+			// Don't block if nobody is waiting, eg. after a timeout
+			select {
+			case w.sync <- s:
+			default:
+			}
+		case strings.TrimSpace(s) != "":
+			w.msgs = append(w.msgs, s)
 		}
-		fmt.Fprintln(pipe, command)
 	}
+	return len(b), nil
 }
 
-// Returns a new gnuplot instance
-func New(persist bool) (Plot, error) {
-	var cmd *exec.Cmd
-	if persist {
-		cmd = exec.Command("gnuplot", "-persist")
-	} else {
-		cmd = exec.Command("gnuplot")
+// take returns the messages so far as an *Error, or nil if there are none
+func (w *errWriter) take(flush bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if flush && len(w.line) > 0 {
+		w.msgs = append(w.msgs, string(w.line))
+		w.line = nil
+	}
+	if len(w.msgs) == 0 {
+		return nil
 	}
-	pipe, err := cmd.StdinPipe()
+	err := &Error{Messages: w.msgs}
+	w.msgs = nil
+	return err
+}
+
+// New starts gnuplot (see Executable). If persist is set, plot windows stay
+// open after the Plot is closed.
+//
+// If NPGO_GNUPLOT selects the script backend, the commands are written to a new script
+// gnuplot-<pid>-<n>.gp instead, see NewScript.
+func New(persist bool) (*Plot, error) {
+	b, err := DefaultBackend()
 	if err != nil {
 		return nil, err
 	}
-	err = cmd.Start()
+	switch b {
+	case Script:
+		n := atomic.AddInt64(&nscript, 1)
+		return NewScript(filepath.Join(scriptDir(), fmt.Sprintf("gnuplot-%d-%d.gp", os.Getpid(), n)))
+	case None:
+		return NewNone(), nil
+	}
+	return NewExec(Executable, persist)
+}
+
+// NewScript returns a Plot that writes its commands to the script fn, which can later
+// be run with gnuplot fn.
+func NewScript(fn string) (*Plot, error) {
+	f, err := os.Create(fn)
 	if err != nil {
 		return nil, err
 	}
+	return &Plot{stdin: &scriptFile{Writer: bufio.NewWriter(f), f: f}, stderr: &errWriter{}}, nil
+}
+
+// scriptFile is a buffered script
+type scriptFile struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func (s *scriptFile) Close() error {
+	err := s.Flush()
+	if err1 := s.f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// nopCloser discards everything written to it
+type nopCloser struct{}
 
-	p := make(chan string)
-	go handleGnuplot(p, pipe)
+func (nopCloser) Write(b []byte) (int, error) { return len(b), nil }
+func (nopCloser) Close() error                { return nil }
+
+// NewNone returns a Plot that discards its commands
+func NewNone() *Plot {
+	return &Plot{stdin: nopCloser{}, stderr: &errWriter{}}
+}
+
+// NewExec is New, running the gnuplot executable at path
+func NewExec(path string, persist bool) (*Plot, error) {
+	var cmd *exec.Cmd
+	if persist {
+		cmd = exec.Command(path, "-persist")
+	} else {
+		cmd = exec.Command(path)
+	}
+	p := &Plot{
+		cmd:    cmd,
+		stderr: &errWriter{sync: make(chan string, 16)},
+		done:   make(chan struct{}),
+	}
+	cmd.Stderr = p.stderr
+	// Persistent plot windows may hold on to stderr after gnuplot exits
+	cmd.WaitDelay = time.Second
+	var err error
+	if p.stdin, err = cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		p.waitErr = cmd.Wait()
+		close(p.done)
+	}()
 	return p, nil
 }
+
+func (p *Plot) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return DefaultTimeout
+}
+
+// exited returns true if gnuplot has exited
+func (p *Plot) exited() bool {
+	if p.cmd == nil {
+		return false
+	}
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// exitErr returns the error for gnuplot exiting early
+func (p *Plot) exitErr() error {
+	if err := p.stderr.take(true); err != nil {
+		return err
+	}
+	if p.waitErr != nil {
+		return fmt.Errorf("gnuplot exited : %v", p.waitErr)
+	}
+	return errors.New("gnuplot exited")
+}
+
+// Cmd sends each of cmds to gnuplot, followed by a newline. Cmd does not wait for
+// gnuplot, so errors in the commands are only reported by later calls to Sync, Err
+// or Close; errors writing to gnuplot are returned, and by all later calls.
+func (p *Plot) Cmd(cmds ...string) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.closed {
+		return errors.New("gnuplot : Plot is closed")
+	}
+	for _, c := range cmds {
+		if _, err := io.WriteString(p.stdin, c+"\n"); err != nil {
+			switch {
+			case p.exited():
+				p.err = p.exitErr()
+			case p.cmd == nil:
+				p.err = err
+			default:
+				p.err = fmt.Errorf("gnuplot : %v", err)
+			}
+			return p.err
+		}
+	}
+	return nil
+}
+
+// Cmdf sends a single formatted command to gnuplot, see Cmd
+func (p *Plot) Cmdf(format string, args ...interface{}) error {
+	return p.Cmd(fmt.Sprintf(format, args...))
+}
+
+// Sync waits for gnuplot to process all the commands sent so far, and returns an *Error
+// if it wrote anything to stderr.
+func (p *Plot) Sync() error {
+	if p.cmd == nil {
+		return p.err
+	}
+	p.nsync++
+	mark := fmt.Sprintf("%s%d", syncPrefix, p.nsync)
+	if err := p.Cmd(fmt.Sprintf("print %q", mark)); err != nil {
+		return err
+	}
+	timer := time.NewTimer(p.timeout())
+	defer timer.Stop()
+	for {
+		select {
+		case m := <-p.stderr.sync:
+			if m == mark {
+				return p.stderr.take(false)
+			}
+		case <-p.done:
+			return p.exitErr()
+		case <-timer.C:
+			return fmt.Errorf("gnuplot : no response after %v", p.timeout())
+		}
+	}
+}
+
+// Err returns an error if writing to gnuplot failed, or an *Error with anything gnuplot
+// has written to stderr since the last call to Sync or Err. Unlike Sync, it does not wait.
+func (p *Plot) Err() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.stderr.take(false)
+}
+
+// Close closes gnuplot's input, and waits for it to exit. If it has not exited after
+// the timeout, it is killed. Close returns an *Error if gnuplot wrote to stderr, or the
+// first error from Cmd.
+func (p *Plot) Close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	if p.cmd == nil {
+		if err := p.stdin.Close(); p.err == nil {
+			p.err = err
+		}
+		return p.err
+	}
+	p.stdin.Close()
+
+	timer := time.NewTimer(p.timeout())
+	defer timer.Stop()
+	select {
+	case <-p.done:
+	case <-timer.C:
+		p.cmd.Process.Kill()
+		<-p.done
+		return fmt.Errorf("gnuplot did not exit after %v, killed", p.timeout())
+	}
+	if err := p.stderr.take(true); err != nil {
+		return err
+	}
+	if p.waitErr != nil {
+		return fmt.Errorf("gnuplot exited : %v", p.waitErr)
+	}
+	return p.err
+}
@@ -0,0 +1,195 @@
+package gnuplot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const fake = "testdata/fake-gnuplot"
+
+func TestCommands(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "log")
+	t.Setenv("FAKE_GNUPLOT_LOG", log)
+	p, err := NewExec(fake, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Cmd("set term png", "plot sin(x)"); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Cmdf("set xrange [%d:%d]", 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Errorf("Second Close : %v", err)
+	}
+	if err = p.Cmd("plot x"); err == nil {
+		t.Error("Expected error after Close")
+	}
+
+	b, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(b), "\n")
+	want := []string{"args: -persist", "set term png", "plot sin(x)", "set xrange [0:10]"}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("Line %d : expected %q, got %q", i, w, lines[i])
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	p, err := NewExec(fake, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.Cmd("set term png", "bad command")
+	err = p.Sync()
+	var gerr *Error
+	if !errors.As(err, &gerr) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if !strings.Contains(gerr.Error(), "invalid command") {
+		t.Errorf("Unexpected error %v", gerr)
+	}
+	// Errors are only reported once
+	if err = p.Sync(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	p.Cmd("print \"hello\"")
+	if err = p.Sync(); err == nil || !strings.Contains(err.Error(), "hello") {
+		t.Errorf("Expected print output, got %v", err)
+	}
+
+	p.Cmd("bad again")
+	if err = p.Close(); err == nil || !strings.Contains(err.Error(), "bad again") {
+		t.Errorf("Expected error from Close, got %v", err)
+	}
+}
+
+func TestCrash(t *testing.T) {
+	p, err := NewExec(fake, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Cmd("crash")
+	if err = p.Sync(); err == nil || !strings.Contains(err.Error(), "crashed") {
+		t.Errorf("Expected crash, got %v", err)
+	}
+	// Writes eventually fail once the pipe is closed
+	for i := 0; i < 100 && err == nil; i++ {
+		err = p.Cmd(strings.Repeat("x", 4096))
+	}
+	if err == nil {
+		t.Error("Expected write error")
+	}
+	if err = p.Close(); err == nil {
+		t.Error("Expected exit error from Close")
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	p, err := NewExec(fake, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Timeout = 100 * time.Millisecond
+	p.Cmd("hang")
+	if err = p.Sync(); err == nil {
+		t.Error("Expected Sync to time out")
+	}
+	start := time.Now()
+	if err = p.Close(); err == nil || !strings.Contains(err.Error(), "killed") {
+		t.Errorf("Expected Close to time out, got %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Close took %v", d)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	defer func(s string) { Executable = s }(Executable)
+	Executable = filepath.Join(t.TempDir(), "no-gnuplot")
+	if _, err := New(false); err == nil {
+		t.Error("Expected error for a missing executable")
+	}
+}
+
+func TestDefaultBackend(t *testing.T) {
+	for s, want := range map[string]Backend{"": Process, "gnuplot": Process, "script": Script, "NONE": None} {
+		t.Setenv(EnvBackend, s)
+		if b, err := DefaultBackend(); err != nil || b != want {
+			t.Errorf("%q : expected %v, got %v %v", s, want, b, err)
+		}
+	}
+	t.Setenv(EnvBackend, "x11")
+	if _, err := New(false); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestScriptBackend(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvBackend, "script")
+	t.Setenv(EnvScriptDir, dir)
+	defer func(s string) { Executable = s }(Executable)
+	Executable = filepath.Join(dir, "no-gnuplot")
+
+	for i := 0; i < 2; i++ {
+		p, err := New(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Cmd("plot '-' w lines", "1 2", "e")
+		if err = p.Sync(); err != nil {
+			t.Error(err)
+		}
+		if err = p.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+	fns, err := filepath.Glob(filepath.Join(dir, "gnuplot-*.gp"))
+	if err != nil || len(fns) != 2 {
+		t.Fatalf("Expected 2 scripts, got %v %v", fns, err)
+	}
+	b, err := os.ReadFile(fns[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "plot '-' w lines\n1 2\ne\n" {
+		t.Errorf("Unexpected script %q", b)
+	}
+}
+
+func TestNoneBackend(t *testing.T) {
+	t.Setenv(EnvBackend, "none")
+	defer func(s string) { Executable = s }(Executable)
+	Executable = filepath.Join(t.TempDir(), "no-gnuplot")
+	p, err := New(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Cmd("bad command"); err != nil {
+		t.Error(err)
+	}
+	if err = p.Sync(); err != nil {
+		t.Error(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,93 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math"
+)
+
+// Grid is a grid, possibly split over MPI ranks, eg. a petsc/grid/fftw3.Grid.
+// Dimensions are the global dimensions; each rank holds the indices [Lo, Hi) in
+// an array with Strides.
+type Grid interface {
+	Dimensions() []int64
+	Strides() []int64
+	Lo() []int64
+	Hi() []int64
+}
+
+// Shaper is implemented by grids whose Dimensions include padding, eg. the last
+// dimension of an in-place real-to-complex FFT grid. Shape returns the logical
+// dimensions, and GridSlice skips the padding.
+type Shaper interface {
+	Shape() []int64
+}
+
+// GridSlice returns a heatmap of the 2D slice of the grid g perpendicular to axis,
+// at index. arr is the local array of the grid on this rank. 2D grids are returned
+// whole, and axis and index are ignored.
+//
+// The heatmap is in grid units (add to Axes.Heatmap, and set DX, DY to rescale), with
+// the remaining dimensions in order as x and y. If g is split over MPI ranks, gather
+// collects the slice onto rank 0; other ranks return nil. If g is a Shaper, the heatmap
+// has its logical dimensions.
+func GridSlice(g Grid, arr []float64, axis int, index int64, gather Gatherer) (*Heatmap, error) {
+	dims, strides, lo, hi := g.Dimensions(), g.Strides(), g.Lo(), g.Hi()
+	if s, ok := g.(Shaper); ok {
+		dims = s.Shape()
+		if len(dims) != len(strides) {
+			return nil, fmt.Errorf("Incompatible dimensions in GridSlice: shape %v, strides %v", dims, strides)
+		}
+	}
+	ndim := len(dims)
+	var ax, ay int
+	switch ndim {
+	case 2:
+		ax, ay, axis = 0, 1, -1
+	case 3:
+		if axis < 0 || axis > 2 {
+			return nil, fmt.Errorf("Slice axis must be 0, 1 or 2, got %d", axis)
+		}
+		if index < 0 || index >= dims[axis] {
+			return nil, fmt.Errorf("Slice index %d out of range [0, %d)", index, dims[axis])
+		}
+		ax, ay = (axis+1)%3, (axis+2)%3
+		if ax > ay {
+			ax, ay = ay, ax
+		}
+	default:
+		return nil, fmt.Errorf("GridSlice needs a 2D or 3D grid, got %dD", ndim)
+	}
+
+	// The local part of the slice, as (ix, iy, value)
+	var local []float64
+	if axis < 0 || (index >= lo[axis] && index < hi[axis]) {
+		var off int64
+		if axis >= 0 {
+			off = (index - lo[axis]) * strides[axis]
+		}
+		// Indices past the logical dimensions are padding
+		for i := lo[ax]; i < min(hi[ax], dims[ax]); i++ {
+			for j := lo[ay]; j < min(hi[ay], dims[ay]); j++ {
+				k := off + (i-lo[ax])*strides[ax] + (j-lo[ay])*strides[ay]
+				if k < 0 || k >= int64(len(arr)) {
+					return nil, fmt.Errorf("Grid array too short : index %d, length %d", k, len(arr))
+				}
+				local = append(local, float64(i), float64(j), arr[k])
+			}
+		}
+	}
+	all, root, err := gatherRoot(gather, local)
+	if err != nil || !root {
+		return nil, err
+	}
+
+	h := &Heatmap{NX: int(dims[ax]), NY: int(dims[ay])}
+	h.Z = make([]float64, h.NX*h.NY)
+	for i := range h.Z {
+		h.Z[i] = math.NaN()
+	}
+	for i := 0; i+2 < len(all); i += 3 {
+		h.Z[int(all[i])*h.NY+int(all[i+1])] = all[i+2]
+	}
+	return h, nil
+}
@@ -0,0 +1,270 @@
+package gnuplot
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// hub fakes MPI gathers between goroutines
+type hub struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	data [][]float64
+	n    int
+}
+
+func newHub(size int) *hub {
+	h := &hub{data: make([][]float64, size)}
+	h.cond = sync.NewCond(&h.mu)
+	return h
+}
+
+type fakeGather struct {
+	rank int
+	h    *hub
+}
+
+func (g fakeGather) Rank() int { return g.rank }
+
+func (g fakeGather) GatherFloat64(x []float64) ([]float64, error) {
+	h := g.h
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.data[g.rank] = append([]float64{}, x...)
+	h.n++
+	h.cond.Broadcast()
+	if g.rank != 0 {
+		return nil, nil
+	}
+	for h.n < len(h.data) {
+		h.cond.Wait()
+	}
+	var out []float64
+	for _, d := range h.data {
+		out = append(out, d...)
+	}
+	return out, nil
+}
+
+// slab is a 3D grid split along the first dimension, with the last dimension padded
+type slab struct {
+	dims, lo, hi []int64
+	arr          []float64
+}
+
+func (s *slab) Dimensions() []int64 { return s.dims }
+func (s *slab) Strides() []int64    { return []int64{s.dims[1] * 4, 4, 1} }
+func (s *slab) Lo() []int64         { return s.lo }
+func (s *slab) Hi() []int64         { return s.hi }
+
+func newSlab(i0, i1 int64) *slab {
+	s := &slab{dims: []int64{4, 3, 2}, lo: []int64{i0, 0, 0}, hi: []int64{i1, 3, 2}}
+	s.arr = make([]float64, (i1-i0)*3*4)
+	for i := i0; i < i1; i++ {
+		for j := int64(0); j < 3; j++ {
+			for k := int64(0); k < 2; k++ {
+				s.arr[(i-i0)*12+j*4+k] = float64(100*i + 10*j + k)
+			}
+		}
+	}
+	return s
+}
+
+// padded is a slab that reports the padding of its last dimension, with a Shape
+type padded struct {
+	*slab
+}
+
+func (p padded) Dimensions() []int64 { return []int64{4, 3, 4} }
+func (p padded) Hi() []int64         { return []int64{p.hi[0], 3, 4} }
+func (p padded) Shape() []int64      { return p.dims }
+
+func TestGridSlice(t *testing.T) {
+	for _, tc := range []struct {
+		axis   int
+		index  int64
+		nx, ny int
+		val    func(i, j int) float64
+	}{
+		{0, 3, 3, 2, func(i, j int) float64 { return float64(300 + 10*i + j) }},
+		{1, 2, 4, 2, func(i, j int) float64 { return float64(100*i + 20 + j) }},
+		{2, 1, 4, 3, func(i, j int) float64 { return float64(100*i + 10*j + 1) }},
+	} {
+		// Serial
+		g := newSlab(0, 4)
+		h, err := GridSlice(g, g.arr, tc.axis, tc.index, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check := func(h *Heatmap) {
+			if h.NX != tc.nx || h.NY != tc.ny {
+				t.Fatalf("axis %d : expected %d x %d, got %d x %d", tc.axis, tc.nx, tc.ny, h.NX, h.NY)
+			}
+			for i := 0; i < h.NX; i++ {
+				for j := 0; j < h.NY; j++ {
+					if z := h.Z[i*h.NY+j]; z != tc.val(i, j) {
+						t.Errorf("axis %d : (%d, %d) expected %g, got %g", tc.axis, i, j, tc.val(i, j), z)
+					}
+				}
+			}
+		}
+		check(h)
+
+		// Two ranks
+		hb := newHub(2)
+		var wg sync.WaitGroup
+		res := make([]*Heatmap, 2)
+		for rank, g := range []*slab{newSlab(0, 1), newSlab(1, 4)} {
+			wg.Add(1)
+			go func(rank int, g *slab) {
+				defer wg.Done()
+				var err error
+				if res[rank], err = GridSlice(g, g.arr, tc.axis, tc.index, fakeGather{rank, hb}); err != nil {
+					t.Error(err)
+				}
+			}(rank, g)
+		}
+		wg.Wait()
+		if res[1] != nil {
+			t.Error("Expected nil heatmap on rank 1")
+		}
+		check(res[0])
+
+		// Padded
+		g = newSlab(0, 4)
+		for i := 2; i < len(g.arr); i += 4 {
+			g.arr[i], g.arr[i+1] = -1, -1
+		}
+		if h, err = GridSlice(padded{g}, g.arr, tc.axis, tc.index, nil); err != nil {
+			t.Fatal(err)
+		}
+		check(h)
+	}
+
+	g := newSlab(0, 4)
+	if _, err := GridSlice(g, g.arr, 3, 0, nil); err == nil {
+		t.Error("Expected error for bad axis")
+	}
+	if _, err := GridSlice(g, g.arr, 0, 4, nil); err == nil {
+		t.Error("Expected error for bad index")
+	}
+	if _, err := GridSlice(g, g.arr[:10], 0, 3, nil); err == nil {
+		t.Error("Expected error for short array")
+	}
+}
+
+type parts [][3]float32
+
+func (p parts) Length() int64                        { return int64(len(p)) }
+func (p parts) GetPos(ipart int64, idim int) float32 { return p[ipart][idim] }
+
+func TestParticleSample(t *testing.T) {
+	p := make(parts, 10000)
+	for i := range p {
+		p[i] = [3]float32{float32(i), float32(2 * i), float32(3 * i)}
+	}
+	rng := rand.New(rand.NewSource(1))
+	s, err := ParticleSample(p, 0.1, []int{2, 0}, rng, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.X); n < 800 || n > 1200 || len(s.Y) != n || s.Z != nil {
+		t.Errorf("Unexpected sample sizes %d %d %d", len(s.X), len(s.Y), len(s.Z))
+	}
+	for i := range s.X {
+		if s.X[i] != 3*s.Y[i] {
+			t.Fatalf("Bad projection %g %g", s.X[i], s.Y[i])
+		}
+	}
+
+	// All the particles, 3D, over 3 ranks
+	hb := newHub(3)
+	var wg sync.WaitGroup
+	res := make([]*Series, 3)
+	for rank := 0; rank < 3; rank++ {
+		wg.Add(1)
+		go func(rank int) {
+			defer wg.Done()
+			var err error
+			if res[rank], err = ParticleSample(p[rank*100:(rank+1)*100], 1, []int{0, 1, 2}, nil, fakeGather{rank, hb}); err != nil {
+				t.Error(err)
+			}
+		}(rank)
+	}
+	wg.Wait()
+	if res[1] != nil || res[2] != nil {
+		t.Error("Expected nil series on ranks 1, 2")
+	}
+	s = res[0]
+	if len(s.X) != 300 || len(s.Z) != 300 {
+		t.Fatalf("Expected 300 particles, got %d", len(s.X))
+	}
+	for i := range s.X {
+		if s.X[i] != float64(i) || s.Y[i] != float64(2*i) || s.Z[i] != float64(3*i) {
+			t.Fatalf("Particle %d : %g %g %g", i, s.X[i], s.Y[i], s.Z[i])
+		}
+	}
+
+	if _, err = ParticleSample(p, 0.1, []int{0}, nil, nil); err == nil {
+		t.Error("Expected error for 1D")
+	}
+	if _, err = ParticleSample(p, 0, []int{0, 1}, nil, nil); err == nil {
+		t.Error("Expected error for zero fraction")
+	}
+}
+
+func TestRender3D(t *testing.T) {
+	g := newSlab(0, 4)
+	h, err := GridSlice(g, g.arr, 2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Z[0] = math.NaN()
+	h.Colormap = "gray"
+	h.Color = Axis{Label: "density", Log: true}
+
+	f := NewFigure(1, 3)
+	f.Axes(0, 0).Heatmap = h
+	p := parts{{1, 2, 3}, {4, 5, 6}}
+	s, err := ParticleSample(p, 1, []int{0, 1, 2}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Axes(0, 1).Add(s)
+	f.Axes(0, 1).Z.Label = "z"
+	f.Axes(0, 2).Plot([]float64{1, 2}, []float64{1, 2}, "")
+
+	var b bytes.Buffer
+	if err = f.Render(&b, "out.png"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"$data0 << EOD\n0.5 0.5 NaN\n0.5 1.5 10\n0.5 2.5 20\n\n1.5 0.5 100\n",
+		"$data1 << EOD\n1 2 3\n4 5 6\nEOD\n",
+		"set pm3d map corners2color c1\nset palette gray\nset cblabel \"density\"\nset logscale cb\n",
+		"splot $data0 using 1:2:3 with pm3d notitle\n",
+		"unset pm3d\nset view 60, 30, 1, 1\n",
+		"set zlabel \"z\"\n",
+		"splot $data1 using 1:2:3 with points notitle pointtype 7 pointsize 0.3\n",
+		"plot $data2 using 1:2 with lines notitle\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Missing %q in\n%s", want, out)
+		}
+	}
+
+	f.Axes(0, 2).Add(s)
+	if err = f.Render(&b, "out.png"); err == nil {
+		t.Error("Expected error for mixed 2D and 3D series")
+	}
+	f = NewFigure(1, 1)
+	f.Axes(0, 0).Heatmap = &Heatmap{NX: 2, NY: 2, Z: []float64{1}}
+	if err = f.Render(&b, "out.png"); err == nil {
+		t.Error("Expected error for bad heatmap")
+	}
+}
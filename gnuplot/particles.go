@@ -0,0 +1,64 @@
+package gnuplot
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Particles is a set of particles, eg. a PW3D.Arr
+type Particles interface {
+	Length() int64
+	GetPos(ipart int64, idim int) float32
+}
+
+// ParticleSample returns a series of a random subsample of the particles p, with each
+// particle kept with probability frac. If dims has two elements, the series is the
+// projection onto those dimensions, eg. {0, 1} for x-y; with three, it is a 3D
+// scatter plot. rng may be nil to use the default source.
+//
+// If the particles are split over MPI ranks, gather collects the sample onto rank 0;
+// other ranks return nil.
+func ParticleSample(p Particles, frac float64, dims []int, rng *rand.Rand, gather Gatherer) (*Series, error) {
+	nd := len(dims)
+	if nd != 2 && nd != 3 {
+		return nil, fmt.Errorf("ParticleSample needs 2 or 3 dimensions, got %v", dims)
+	}
+	if frac <= 0 || frac > 1 {
+		return nil, fmt.Errorf("Sampling fraction must be in (0, 1], got %g", frac)
+	}
+	random := rand.Float64
+	if rng != nil {
+		random = rng.Float64
+	}
+
+	var local []float64
+	n := p.Length()
+	for i := int64(0); i < n; i++ {
+		if frac < 1 && random() >= frac {
+			continue
+		}
+		for _, d := range dims {
+			local = append(local, float64(p.GetPos(i, d)))
+		}
+	}
+	all, root, err := gatherRoot(gather, local)
+	if err != nil || !root {
+		return nil, err
+	}
+
+	npart := len(all) / nd
+	s := &Series{Style: Points, PointType: 7, PointSize: 0.3}
+	s.X = make([]float64, npart)
+	s.Y = make([]float64, npart)
+	if nd == 3 {
+		s.Z = make([]float64, npart)
+	}
+	for i := 0; i < npart; i++ {
+		s.X[i] = all[i*nd]
+		s.Y[i] = all[i*nd+1]
+		if nd == 3 {
+			s.Z[i] = all[i*nd+2]
+		}
+	}
+	return s, nil
+}
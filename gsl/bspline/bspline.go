@@ -0,0 +1,329 @@
+// Package bspline wraps the GSL basis spline routines, and uses them to build
+// least-squares smoothing splines.
+//
+// Unlike the interpolating splines in gsl/spline, a smoothing spline does not pass
+// through the data points, making it better suited to noisy data (eg. n(z) histograms).
+package bspline
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_bspline.h>
+#include <gsl/gsl_multifit.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// BSpline wraps the GSL B-spline workspaces. The order k is the polynomial degree + 1,
+// so cubic splines have k=4.
+//
+// A BSpline holds internal scratch space, and so must not be used concurrently.
+type BSpline struct {
+	k, nbreak, ncoeffs int
+	lo, hi             float64
+	w                  *C.gsl_bspline_workspace
+	dw                 *C.gsl_bspline_deriv_workspace
+	b, tmp             *C.gsl_vector
+	db                 *C.gsl_matrix
+}
+
+func alloc(k, nbreak int) (*BSpline, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("Spline order must be positive, got %d", k)
+	}
+	if nbreak < 2 {
+		return nil, fmt.Errorf("Need at least two breakpoints, got %d", nbreak)
+	}
+	bs := new(BSpline)
+	bs.k = k
+	bs.nbreak = nbreak
+	bs.w = C.gsl_bspline_alloc(C.size_t(k), C.size_t(nbreak))
+	bs.dw = C.gsl_bspline_deriv_alloc(C.size_t(k))
+	bs.ncoeffs = int(C.gsl_bspline_ncoeffs(bs.w))
+	bs.b = C.gsl_vector_alloc(C.size_t(bs.ncoeffs))
+	bs.tmp = C.gsl_vector_alloc(C.size_t(bs.ncoeffs))
+	return bs, nil
+}
+
+// NewUniform returns a B-spline basis of order k with nbreak uniformly spaced breakpoints
+// spanning [lo, hi].
+func NewUniform(k, nbreak int, lo, hi float64) (*BSpline, error) {
+	if hi <= lo {
+		return nil, fmt.Errorf("Empty interval in NewUniform: [%f, %f]", lo, hi)
+	}
+	bs, err := alloc(k, nbreak)
+	if err != nil {
+		return nil, err
+	}
+	ret := C.gsl_bspline_knots_uniform(C.double(lo), C.double(hi), bs.w)
+	if ret != 0 {
+		bs.Free()
+		return nil, gsl.Errno(ret)
+	}
+	bs.lo, bs.hi = lo, hi
+	return bs, nil
+}
+
+// New returns a B-spline basis of order k with the specified breakpoints, which must be
+// strictly increasing.
+func New(k int, breaks []float64) (*BSpline, error) {
+	for i := 1; i < len(breaks); i++ {
+		if breaks[i] <= breaks[i-1] {
+			return nil, errors.New("Breakpoints must be strictly increasing")
+		}
+	}
+	bs, err := alloc(k, len(breaks))
+	if err != nil {
+		return nil, err
+	}
+	bv := C.gsl_vector_alloc(C.size_t(len(breaks)))
+	defer C.gsl_vector_free(bv)
+	setVector(bv, breaks)
+	ret := C.gsl_bspline_knots(bv, bs.w)
+	if ret != 0 {
+		bs.Free()
+		return nil, gsl.Errno(ret)
+	}
+	bs.lo, bs.hi = breaks[0], breaks[len(breaks)-1]
+	return bs, nil
+}
+
+// Free frees the B-spline workspaces
+func (bs *BSpline) Free() {
+	if bs.db != nil {
+		C.gsl_matrix_free(bs.db)
+	}
+	C.gsl_vector_free(bs.tmp)
+	C.gsl_vector_free(bs.b)
+	C.gsl_bspline_deriv_free(bs.dw)
+	C.gsl_bspline_free(bs.w)
+}
+
+// NCoeffs returns the number of basis functions
+func (bs *BSpline) NCoeffs() int {
+	return bs.ncoeffs
+}
+
+// Order returns the order of the spline
+func (bs *BSpline) Order() int {
+	return bs.k
+}
+
+// Interval returns the interval spanned by the breakpoints
+func (bs *BSpline) Interval() gsl.Interval {
+	return gsl.Interval{Lo: bs.lo, Hi: bs.hi}
+}
+
+// Breakpoints returns a copy of the breakpoints
+func (bs *BSpline) Breakpoints() []float64 {
+	arr := make([]float64, bs.nbreak)
+	for i := range arr {
+		arr[i] = float64(C.gsl_bspline_breakpoint(C.size_t(i), bs.w))
+	}
+	return arr
+}
+
+func (bs *BSpline) checkDomain(x float64) error {
+	if x < bs.lo || x > bs.hi {
+		return gsl.GSL_EDOM
+	}
+	return nil
+}
+
+// evalDeriv fills in bs.db with the first nderiv derivatives at x.
+func (bs *BSpline) evalDeriv(x float64, nderiv int) error {
+	if nderiv < 0 {
+		return fmt.Errorf("Derivative order must be non-negative, got %d", nderiv)
+	}
+	if err := bs.checkDomain(x); err != nil {
+		return err
+	}
+	if bs.db == nil || int(bs.db.size2) < nderiv+1 {
+		if bs.db != nil {
+			C.gsl_matrix_free(bs.db)
+		}
+		bs.db = C.gsl_matrix_alloc(C.size_t(bs.ncoeffs), C.size_t(nderiv+1))
+	}
+	ret := C.gsl_bspline_deriv_eval(C.double(x), C.size_t(nderiv), bs.db, bs.w, bs.dw)
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	return nil
+}
+
+// Basis fills B with the values of the basis functions at x. B must have length NCoeffs().
+func (bs *BSpline) Basis(x float64, B []float64) error {
+	if len(B) != bs.ncoeffs {
+		return fmt.Errorf("Incompatible dimensions in Basis: B(%d) != ncoeffs(%d)", len(B), bs.ncoeffs)
+	}
+	if err := bs.checkDomain(x); err != nil {
+		return err
+	}
+	ret := C.gsl_bspline_eval(C.double(x), bs.b, bs.w)
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	getVector(bs.b, B)
+	return nil
+}
+
+// BasisDeriv fills dB with the nderiv-th derivative of the basis functions at x.
+// dB must have length NCoeffs().
+func (bs *BSpline) BasisDeriv(x float64, nderiv int, dB []float64) error {
+	if len(dB) != bs.ncoeffs {
+		return fmt.Errorf("Incompatible dimensions in BasisDeriv: dB(%d) != ncoeffs(%d)", len(dB), bs.ncoeffs)
+	}
+	if err := bs.evalDeriv(x, nderiv); err != nil {
+		return err
+	}
+	C.gsl_matrix_get_col(bs.tmp, bs.db, C.size_t(nderiv))
+	getVector(bs.tmp, dB)
+	return nil
+}
+
+// Smooth is a least-squares fit of a B-spline basis to data.
+//
+// Smooth keeps a reference to the basis it was fit with, so the basis must not be
+// freed while the fit is in use.
+type Smooth struct {
+	bs    *BSpline
+	c     *C.gsl_vector
+	cov   *C.gsl_matrix
+	Chisq float64 // Weighted chi-squared of the fit, or the sum of squared residuals if unweighted
+	Dof   int     // Degrees of freedom, number of points - NCoeffs()
+}
+
+// Fit does a weighted linear least-squares fit of the basis to the points (x, y).
+// The weights are typically 1/sigma^2. If w is nil, all points are equally weighted,
+// and the errors are estimated from the scatter about the fit: the covariance (and so
+// the errors from Eval and Deriv) is scaled by Chisq/Dof.
+// All x must lie within the interval spanned by the breakpoints.
+func (bs *BSpline) Fit(x, y, w []float64) (*Smooth, error) {
+	n := len(x)
+	if len(y) != n {
+		return nil, fmt.Errorf("Incompatible dimensions in Fit: x(%d) != y(%d)", n, len(y))
+	}
+	if w != nil && len(w) != n {
+		return nil, fmt.Errorf("Incompatible dimensions in Fit: x(%d) != w(%d)", n, len(w))
+	}
+	if n < bs.ncoeffs {
+		return nil, fmt.Errorf("Too few points in Fit: %d points < %d coefficients", n, bs.ncoeffs)
+	}
+
+	// Build the design matrix
+	X := C.gsl_matrix_alloc(C.size_t(n), C.size_t(bs.ncoeffs))
+	defer C.gsl_matrix_free(X)
+	for i, x1 := range x {
+		if err := bs.checkDomain(x1); err != nil {
+			return nil, err
+		}
+		if ret := C.gsl_bspline_eval(C.double(x1), bs.b, bs.w); ret != 0 {
+			return nil, gsl.Errno(ret)
+		}
+		C.gsl_matrix_set_row(X, C.size_t(i), bs.b)
+	}
+
+	yv := C.gsl_vector_alloc(C.size_t(n))
+	defer C.gsl_vector_free(yv)
+	setVector(yv, y)
+	wv := C.gsl_vector_alloc(C.size_t(n))
+	defer C.gsl_vector_free(wv)
+	if w != nil {
+		setVector(wv, w)
+	} else {
+		C.gsl_vector_set_all(wv, 1)
+	}
+
+	work := C.gsl_multifit_linear_alloc(C.size_t(n), C.size_t(bs.ncoeffs))
+	defer C.gsl_multifit_linear_free(work)
+
+	s := new(Smooth)
+	s.bs = bs
+	s.c = C.gsl_vector_alloc(C.size_t(bs.ncoeffs))
+	s.cov = C.gsl_matrix_alloc(C.size_t(bs.ncoeffs), C.size_t(bs.ncoeffs))
+	var chisq C.double
+	ret := C.gsl_multifit_wlinear(X, wv, yv, s.c, s.cov, &chisq, work)
+	if ret != 0 {
+		s.Free()
+		return nil, gsl.Errno(ret)
+	}
+	s.Chisq = float64(chisq)
+	s.Dof = n - bs.ncoeffs
+	if w == nil && s.Dof > 0 {
+		C.gsl_matrix_scale(s.cov, chisq/C.double(s.Dof))
+	}
+	return s, nil
+}
+
+// Free frees the coefficients and covariance matrix, but not the basis
+func (s *Smooth) Free() {
+	C.gsl_matrix_free(s.cov)
+	C.gsl_vector_free(s.c)
+}
+
+// Coeffs returns a copy of the fit coefficients
+func (s *Smooth) Coeffs() []float64 {
+	arr := make([]float64, s.bs.ncoeffs)
+	getVector(s.c, arr)
+	return arr
+}
+
+// Eval evaluates the fit at x. The error is propagated from the covariance of the fit
+// coefficients.
+func (s *Smooth) Eval(x float64) (gsl.Result, error) {
+	if err := s.bs.checkDomain(x); err != nil {
+		return gsl.Result{}, err
+	}
+	if ret := C.gsl_bspline_eval(C.double(x), s.bs.b, s.bs.w); ret != 0 {
+		return gsl.Result{}, gsl.Errno(ret)
+	}
+	return s.est(s.bs.b)
+}
+
+// Deriv evaluates the nderiv-th derivative of the fit at x, with an error estimate.
+func (s *Smooth) Deriv(x float64, nderiv int) (gsl.Result, error) {
+	if err := s.bs.evalDeriv(x, nderiv); err != nil {
+		return gsl.Result{}, err
+	}
+	C.gsl_matrix_get_col(s.bs.tmp, s.bs.db, C.size_t(nderiv))
+	return s.est(s.bs.tmp)
+}
+
+func (s *Smooth) est(b *C.gsl_vector) (gsl.Result, error) {
+	var y, yerr C.double
+	ret := C.gsl_multifit_linear_est(b, s.c, s.cov, &y, &yerr)
+	if ret != 0 {
+		return gsl.Result{Res: float64(y), Err: float64(yerr)}, gsl.Errno(ret)
+	}
+	return gsl.Result{Res: float64(y), Err: float64(yerr)}, nil
+}
+
+// Func returns the fit as a gsl.F, dropping the error estimate.
+// The returned function panics if evaluated outside the interval.
+func (s *Smooth) Func() gsl.F {
+	return func(x float64) float64 {
+		res, err := s.Eval(x)
+		if err != nil {
+			panic(err)
+		}
+		return res.Res
+	}
+}
+
+func setVector(v *C.gsl_vector, arr []float64) {
+	for i, x := range arr {
+		C.gsl_vector_set(v, C.size_t(i), C.double(x))
+	}
+}
+
+func getVector(v *C.gsl_vector, arr []float64) {
+	for i := range arr {
+		arr[i] = float64(C.gsl_vector_get(v, C.size_t(i)))
+	}
+}
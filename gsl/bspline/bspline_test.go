@@ -0,0 +1,150 @@
+package bspline
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBasis(t *testing.T) {
+	bs, err := NewUniform(4, 10, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Free()
+
+	if bs.NCoeffs() != 12 {
+		t.Errorf("Incorrect number of coefficients: expected = %d, actual = %d", 12, bs.NCoeffs())
+	}
+
+	// The basis functions are a partition of unity
+	B := make([]float64, bs.NCoeffs())
+	for _, x := range []float64{0, 0.13, 0.5, 0.77, 1} {
+		if err = bs.Basis(x, B); err != nil {
+			t.Errorf("Unexpected error returned : %v", err)
+		}
+		sum := 0.0
+		for _, b := range B {
+			sum += b
+		}
+		if math.Abs(sum-1) > 1.e-12 {
+			t.Errorf("Basis does not sum to 1 at x=%f : %f", x, sum)
+		}
+	}
+
+	// And so their derivatives sum to zero
+	if err = bs.BasisDeriv(0.3, 1, B); err != nil {
+		t.Errorf("Unexpected error returned : %v", err)
+	}
+	sum := 0.0
+	for _, b := range B {
+		sum += b
+	}
+	if math.Abs(sum) > 1.e-10 {
+		t.Errorf("Basis derivative does not sum to 0 : %f", sum)
+	}
+
+	// Test out of domain
+	if err = bs.Basis(1.5, B); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestBreakpoints(t *testing.T) {
+	breaks := []float64{0, 0.1, 0.3, 0.7, 1.0}
+	bs, err := New(4, breaks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Free()
+	out := bs.Breakpoints()
+	for i := range breaks {
+		if math.Abs(out[i]-breaks[i]) > 1.e-12 {
+			t.Errorf("Incorrect breakpoint: expected = %f, actual = %f", breaks[i], out[i])
+		}
+	}
+
+	if _, err = New(4, []float64{0, 1, 0.5}); err == nil {
+		t.Error("Expected an error for unordered breakpoints, none reported")
+	}
+}
+
+func TestFit(t *testing.T) {
+	n := 200
+	x := make([]float64, n)
+	y := make([]float64, n)
+	w := make([]float64, n)
+	for i := range x {
+		x[i] = 2 * math.Pi * float64(i) / float64(n-1)
+		// Add a deterministic "noise" term
+		y[i] = math.Sin(x[i]) + 0.01*math.Cos(37*x[i])
+		w[i] = 1 / (0.01 * 0.01)
+	}
+
+	bs, err := NewUniform(4, 12, 0, 2*math.Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bs.Free()
+
+	sm, err := bs.Fit(x, y, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm.Free()
+
+	if sm.Dof != n-bs.NCoeffs() {
+		t.Errorf("Incorrect degrees of freedom: expected = %d, actual = %d", n-bs.NCoeffs(), sm.Dof)
+	}
+
+	for _, x1 := range []float64{0.5, 1.7, 3, 4.4} {
+		res, err := sm.Eval(x1)
+		if err != nil {
+			t.Errorf("Unexpected error returned : %v", err)
+		}
+		if math.Abs(res.Res-math.Sin(x1)) > 5.e-3 {
+			t.Errorf("Incorrect value: expected = %f, actual = %f", math.Sin(x1), res.Res)
+		}
+		if res.Err <= 0 {
+			t.Errorf("Expected a positive error estimate, got %f", res.Err)
+		}
+
+		res, err = sm.Deriv(x1, 1)
+		if err != nil {
+			t.Errorf("Unexpected error in derivative : %v", err)
+		}
+		if math.Abs(res.Res-math.Cos(x1)) > 2.e-2 {
+			t.Errorf("Incorrect derivative: expected = %f, actual = %f", math.Cos(x1), res.Res)
+		}
+	}
+
+	ff := sm.Func()
+	if math.Abs(ff(1)-math.Sin(1)) > 5.e-3 {
+		t.Errorf("Incorrect value: expected = %f, actual = %f", math.Sin(1), ff(1))
+	}
+
+	// Test out of domain
+	if _, err = sm.Eval(7); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+
+	// Without weights, the errors come from the scatter about the fit. With equal weights,
+	// this rescales the weighted errors by sqrt(chisq/dof).
+	sm1, err := bs.Fit(x, y, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sm1.Free()
+	scale := math.Sqrt(sm.Chisq / float64(sm.Dof))
+	for _, x1 := range []float64{0.5, 3} {
+		res, _ := sm.Eval(x1)
+		res1, _ := sm1.Eval(x1)
+		if math.Abs(res1.Res-res.Res) > 1.e-10 || math.Abs(res1.Err-scale*res.Err) > 1.e-6*res1.Err {
+			t.Errorf("Unweighted fit : expected %f +- %g, got %v", res.Res, scale*res.Err, res1)
+		}
+	}
+
+	// Mismatched inputs
+	if _, err = bs.Fit(x, y[1:], nil); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
@@ -0,0 +1,155 @@
+// Package cheb wraps the GSL Chebyshev approximations.
+//
+// A Chebyshev series is fit once to a smooth function on an interval, and can then be
+// evaluated (along with its derivative and integral) much more cheaply than the
+// original function, eg. for growth factors or distance integrals.
+package cheb
+
+/*
+#cgo pkg-config: gsl
+
+#include <stdint.h>
+#include <gsl/gsl_chebyshev.h>
+
+extern double chebCB(double x, void *params);
+
+// The params are a cgo.Handle for the Go function
+static gsl_function mkchebCB(uintptr_t h) {
+	gsl_function gf;
+	gf.function = chebCB;
+	gf.params = (void *) h;
+	return gf;
+}
+
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+//export chebCB
+func chebCB(x C.double, data unsafe.Pointer) C.double {
+	ff := cgo.Handle(uintptr(data)).Value().(gsl.F)
+	return C.double(ff(float64(x)))
+}
+
+// Series is a Chebyshev series on an interval
+type Series struct {
+	cs *C.gsl_cheb_series
+}
+
+func alloc(order int) (*Series, error) {
+	if order < 1 {
+		return nil, fmt.Errorf("Order must be positive, got %d", order)
+	}
+	s := new(Series)
+	s.cs = C.gsl_cheb_alloc(C.size_t(order))
+	if s.cs == nil {
+		return nil, gsl.GSL_ENOMEM
+	}
+	return s, nil
+}
+
+// New fits a Chebyshev series of the given order to ff on the interval ab.
+// ff is evaluated order+1 times.
+func New(ff gsl.F, order int, ab gsl.Interval) (*Series, error) {
+	if ab.Hi <= ab.Lo {
+		return nil, fmt.Errorf("Empty interval in New: [%f, %f]", ab.Lo, ab.Hi)
+	}
+	s, err := alloc(order)
+	if err != nil {
+		return nil, err
+	}
+	h := cgo.NewHandle(ff)
+	defer h.Delete()
+	gf := C.mkchebCB(C.uintptr_t(h))
+	ret := C.gsl_cheb_init(s.cs, &gf, C.double(ab.Lo), C.double(ab.Hi))
+	if ret != 0 {
+		s.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return s, nil
+}
+
+// Free frees the series; it is safe to call more than once.
+func (s *Series) Free() {
+	if s.cs != nil {
+		C.gsl_cheb_free(s.cs)
+		s.cs = nil
+	}
+}
+
+// Order returns the order of the series
+func (s *Series) Order() int {
+	return int(C.gsl_cheb_order(s.cs))
+}
+
+// Interval returns the interval the series was fit on
+func (s *Series) Interval() gsl.Interval {
+	return gsl.Interval{Lo: float64(s.cs.a), Hi: float64(s.cs.b)}
+}
+
+// Coeffs returns a copy of the Chebyshev coefficients. Note that, as in GSL, the
+// series is c[0]/2 + sum_n c[n] T_n(x).
+func (s *Series) Coeffs() []float64 {
+	n := int(C.gsl_cheb_size(s.cs))
+	c := unsafe.Slice((*float64)(unsafe.Pointer(C.gsl_cheb_coeffs(s.cs))), n)
+	return append([]float64(nil), c...)
+}
+
+// Eval evaluates the series at x. The series is not meaningful outside its interval.
+func (s *Series) Eval(x float64) float64 {
+	return float64(C.gsl_cheb_eval(s.cs, C.double(x)))
+}
+
+// EvalErr evaluates the series at x, with an error estimate from the truncated terms.
+func (s *Series) EvalErr(x float64) gsl.Result {
+	var y, err C.double
+	C.gsl_cheb_eval_err(s.cs, C.double(x), &y, &err)
+	return gsl.Result{Res: float64(y), Err: float64(err)}
+}
+
+// EvalN evaluates the series at x using at most order terms, with an error estimate.
+func (s *Series) EvalN(order int, x float64) gsl.Result {
+	var y, err C.double
+	C.gsl_cheb_eval_n_err(s.cs, C.size_t(order), C.double(x), &y, &err)
+	return gsl.Result{Res: float64(y), Err: float64(err)}
+}
+
+// Deriv returns the series for the derivative
+func (s *Series) Deriv() (*Series, error) {
+	d, err := alloc(s.Order())
+	if err != nil {
+		return nil, err
+	}
+	ret := C.gsl_cheb_calc_deriv(d.cs, s.cs)
+	if ret != 0 {
+		d.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return d, nil
+}
+
+// Integ returns the series for the integral, from the lower end of the interval
+func (s *Series) Integ() (*Series, error) {
+	d, err := alloc(s.Order())
+	if err != nil {
+		return nil, err
+	}
+	ret := C.gsl_cheb_calc_integ(d.cs, s.cs)
+	if ret != 0 {
+		d.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return d, nil
+}
+
+// Func returns the series as a gsl.F
+func (s *Series) Func() gsl.F {
+	return s.Eval
+}
@@ -0,0 +1,65 @@
+package cheb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/nptest"
+)
+
+var eps = nptest.NewEps(1.e-10, 1.e-10)
+
+func TestSeries(t *testing.T) {
+	ab := gsl.Interval{Lo: 0, Hi: 2}
+	s, err := New(math.Exp, 30, ab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Free()
+	if s.Order() != 30 {
+		t.Errorf("Incorrect order : %d", s.Order())
+	}
+	if iv := s.Interval(); iv != ab {
+		t.Errorf("Incorrect interval : %v", iv)
+	}
+	if len(s.Coeffs()) != 31 {
+		t.Errorf("Incorrect number of coefficients : %d", len(s.Coeffs()))
+	}
+
+	d, err := s.Deriv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Free()
+	in, err := s.Integ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Free is safe to call twice
+	defer in.Free()
+	defer in.Free()
+
+	for _, x := range []float64{0, 0.3, 1.1, 2} {
+		eps.EqFloat64(math.Exp(x), s.Eval(x), "Eval", t)
+		eps.EqFloat64(math.Exp(x), d.Eval(x), "Deriv", t)
+		eps.EqFloat64(math.Exp(x)-1, in.Eval(x), "Integ", t)
+		res := s.EvalErr(x)
+		if math.Abs(res.Res-math.Exp(x)) > 1.e-10 || res.Err > 1.e-10 {
+			t.Errorf("EvalErr : %v", res)
+		}
+	}
+
+	// Truncating the series makes it less accurate
+	res := s.EvalN(3, 1.5)
+	if math.Abs(res.Res-math.Exp(1.5)) < 1.e-6 || res.Err <= 0 {
+		t.Errorf("EvalN : %v", res)
+	}
+
+	ff := s.Func()
+	eps.EqFloat64(math.Exp(0.7), ff(0.7), "Func", t)
+
+	if _, err = New(math.Exp, 10, gsl.Interval{Lo: 1, Hi: 0}); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
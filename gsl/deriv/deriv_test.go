@@ -70,3 +70,89 @@ func TestDeriv2(t *testing.T) {
 		t.Errorf("Derivative failed : x=%f, expected=%f, actual=%f, error=%f", x, y, res.Res, res.Err)
 	}
 }
+
+func TestDiffN(t *testing.T) {
+	x := 0.7
+	truth := []float64{math.Cos(x), -math.Sin(x), -math.Cos(x), math.Sin(x)}
+	for n := 1; n <= 4; n++ {
+		res, err := DiffN(n, math.Sin, x, 0.5)
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if math.Abs(res.Res-truth[n-1]) > 1.e-6 {
+			t.Errorf("Derivative %d failed : x=%f, expected=%f, actual=%f, error=%f", n, x, truth[n-1], res.Res, res.Err)
+		}
+	}
+
+	if _, err := DiffN(0, math.Sin, x, 0.5); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestDiffAuto(t *testing.T) {
+	x := 3.0
+	res, h, err := DiffAuto(Central, math.Exp, x)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if h <= 0 {
+		t.Errorf("Unexpected step size %f", h)
+	}
+	if math.Abs(res.Res-math.Exp(x)) > 1.e-8 {
+		t.Errorf("Derivative failed : x=%f, expected=%f, actual=%f, error=%f", x, math.Exp(x), res.Res, res.Err)
+	}
+}
+
+func TestDiffArr(t *testing.T) {
+	xx := []float64{0, 0.5, 1, 2}
+	out := make([]gsl.Result, len(xx))
+	if err := DiffArr(Central, math.Sin, xx, 0.001, out); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	for i, x := range xx {
+		if math.Abs(out[i].Res-math.Cos(x)) > 1.e-8 {
+			t.Errorf("Derivative failed : x=%f, expected=%f, actual=%f, error=%f", x, math.Cos(x), out[i].Res, out[i].Err)
+		}
+	}
+	if err := DiffArr(Central, math.Sin, xx, 0.001, out[1:]); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestGradient(t *testing.T) {
+	ff := func(x []float64) float64 { return x[0]*x[0]*x[1] + math.Sin(x[1]) }
+	x := []float64{1.5, 0.3}
+	grad, err := Gradient(ff, x, []float64{0.01, 0.01})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	truth := []float64{2 * x[0] * x[1], x[0]*x[0] + math.Cos(x[1])}
+	for i := range truth {
+		if math.Abs(grad[i].Res-truth[i]) > 1.e-8 {
+			t.Errorf("Gradient failed : i=%d, expected=%f, actual=%f, error=%f", i, truth[i], grad[i].Res, grad[i].Err)
+		}
+	}
+	// x should not be modified
+	if x[0] != 1.5 || x[1] != 0.3 {
+		t.Errorf("Input was modified : %v", x)
+	}
+}
+
+func TestJacobian(t *testing.T) {
+	ff := func(x []float64) []float64 {
+		return []float64{x[0] * x[1], math.Exp(x[0]), x[1] * x[1] * x[1]}
+	}
+	x := []float64{0.5, 2}
+	jac, err := Jacobian(ff, x, []float64{0.01, 0.01})
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	truth := [][]float64{{x[1], x[0]}, {math.Exp(x[0]), 0}, {0, 3 * x[1] * x[1]}}
+	for i := range truth {
+		for j := range truth[i] {
+			if math.Abs(jac[i][j].Res-truth[i][j]) > 1.e-7 {
+				t.Errorf("Jacobian failed : i=%d, j=%d, expected=%f, actual=%f, error=%f", i, j, truth[i][j], jac[i][j].Res, jac[i][j].Err)
+			}
+		}
+	}
+}
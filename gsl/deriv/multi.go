@@ -0,0 +1,95 @@
+package deriv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// MultiF is a scalar function of many variables
+type MultiF func([]float64) float64
+
+// VecF is a vector-valued function of many variables
+type VecF func([]float64) []float64
+
+// Gradient computes the gradient of ff at x, using central differences in each
+// direction. h holds the step size in each direction, and must be the same length as x.
+func Gradient(ff MultiF, x, h []float64) ([]gsl.Result, error) {
+	if len(h) != len(x) {
+		return nil, fmt.Errorf("Incompatible dimensions in Gradient: x(%d) != h(%d)", len(x), len(h))
+	}
+	xx := make([]float64, len(x))
+	copy(xx, x)
+	grad := make([]gsl.Result, len(x))
+	var err error
+	for i := range x {
+		f1 := func(t float64) float64 {
+			xx[i] = t
+			return ff(xx)
+		}
+		grad[i], err = Diff(Central, f1, x[i], h[i])
+		xx[i] = x[i]
+		if err != nil {
+			return grad, err
+		}
+	}
+	return grad, nil
+}
+
+// Jacobian computes J[i][j] = d ff_i / d x_j at x. h holds the step size in each direction,
+// and must be the same length as x.
+//
+// This uses the same 5-point rule and error estimate as Diff(Central,...), but
+// without the step-size refinement. All components of ff are differentiated
+// together, so ff is evaluated only 4 times per direction. ff must return a new
+// slice on every call.
+func Jacobian(ff VecF, x, h []float64) ([][]gsl.Result, error) {
+	if len(h) != len(x) {
+		return nil, fmt.Errorf("Incompatible dimensions in Jacobian: x(%d) != h(%d)", len(x), len(h))
+	}
+	xx := make([]float64, len(x))
+	copy(xx, x)
+	eval := func(j int, t float64) []float64 {
+		xx[j] = t
+		return ff(xx)
+	}
+
+	var jac [][]gsl.Result
+	for j := range x {
+		x0, h0 := x[j], h[j]
+		fm1 := eval(j, x0-h0)
+		fp1 := eval(j, x0+h0)
+		fmh := eval(j, x0-h0/2)
+		fph := eval(j, x0+h0/2)
+		xx[j] = x0
+
+		m := len(fm1)
+		if len(fp1) != m || len(fmh) != m || len(fph) != m {
+			return nil, errors.New("Function returned inconsistent lengths in Jacobian")
+		}
+		if jac == nil {
+			jac = make([][]gsl.Result, m)
+			for i := range jac {
+				jac[i] = make([]gsl.Result, len(x))
+			}
+		} else if len(jac) != m {
+			return nil, errors.New("Function returned inconsistent lengths in Jacobian")
+		}
+
+		for i := 0; i < m; i++ {
+			r3 := 0.5 * (fp1[i] - fm1[i])
+			r5 := (4.0/3.0)*(fph[i]-fmh[i]) - (1.0/3.0)*r3
+			e3 := (math.Abs(fp1[i]) + math.Abs(fm1[i])) * dblEpsilon
+			e5 := 2.0*(math.Abs(fph[i])+math.Abs(fmh[i]))*dblEpsilon + e3
+			dy := math.Max(math.Abs(r3/h0), math.Abs(r5/h0)) * (math.Abs(x0) / h0) * dblEpsilon
+			jac[i][j].Res = r5 / h0
+			jac[i][j].Err = math.Abs((r5-r3)/h0) + math.Abs(e5/h0) + dy
+		}
+	}
+	return jac, nil
+}
+
+// dblEpsilon is GSL_DBL_EPSILON
+const dblEpsilon = 2.2204460492503131e-16
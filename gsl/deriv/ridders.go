@@ -0,0 +1,105 @@
+package deriv
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Parameters for Ridders' method. The step size is reduced by riddersCon at every
+// step, for at most riddersNtab steps. The iteration stops when the error grows by more
+// than riddersSafe over the best estimate so far.
+const (
+	riddersCon  = 1.4
+	riddersCon2 = riddersCon * riddersCon
+	riddersNtab = 10
+	riddersSafe = 2.0
+)
+
+// centralN computes the n-th central difference of ff at x, with step h.
+// The stencil is symmetric, so the truncation error is a series in h^2.
+func centralN(ff gsl.F, n int, x, h float64) float64 {
+	sum := 0.0
+	c := 1.0 // (-1)^k binomial(n, k)
+	for k := 0; k <= n; k++ {
+		sum += c * ff(x+(float64(n)/2-float64(k))*h)
+		c *= -float64(n-k) / float64(k+1)
+	}
+	return sum / math.Pow(h, float64(n))
+}
+
+// DiffN computes the n-th derivative of ff at x, using Richardson extrapolation of
+// central differences (Ridders' method).
+//
+// h is the initial step size, which is successively reduced. Unlike Diff, h should be
+// large, of order the scale over which ff changes appreciably; the extrapolation
+// takes care of the truncation error.
+func DiffN(n int, ff gsl.F, x, h float64) (gsl.Result, error) {
+	if n < 1 {
+		return gsl.Result{}, fmt.Errorf("Derivative order must be positive, got %d", n)
+	}
+	if h <= 0 {
+		return gsl.Result{}, errors.New("Step size must be positive")
+	}
+
+	var a [riddersNtab][riddersNtab]float64
+	hh := h
+	a[0][0] = centralN(ff, n, x, hh)
+	ans, err := a[0][0], math.MaxFloat64
+	for i := 1; i < riddersNtab; i++ {
+		hh /= riddersCon
+		a[i][0] = centralN(ff, n, x, hh)
+		fac := riddersCon2
+		for j := 1; j <= i; j++ {
+			// Extrapolate to zero step size
+			a[i][j] = (a[i][j-1]*fac - a[i-1][j-1]) / (fac - 1)
+			fac *= riddersCon2
+			errt := math.Max(math.Abs(a[i][j]-a[i][j-1]), math.Abs(a[i][j]-a[i-1][j-1]))
+			if errt <= err {
+				ans, err = a[i][j], errt
+			}
+		}
+		// Stop if higher order is worse
+		if math.Abs(a[i][i]-a[i-1][i-1]) >= riddersSafe*err {
+			break
+		}
+	}
+	return gsl.Result{Res: ans, Err: err}, nil
+}
+
+// DiffAuto computes the derivative of ff at x, choosing the step size that minimizes
+// the error estimate returned by Diff. The step sizes tried range from 0.1 to 1.e-8,
+// scaled by max(|x|, 1).
+//
+// Returns the derivative and the step size used.
+func DiffAuto(dir DerivType, ff gsl.F, x float64) (gsl.Result, float64, error) {
+	scale := math.Max(math.Abs(x), 1)
+	var best gsl.Result
+	var hbest float64
+	var besterr error
+	for i := 2; i <= 16; i++ {
+		h := scale * math.Pow(10, -float64(i)/2)
+		res, err := Diff(dir, ff, x, h)
+		if i == 2 || (err == nil && (besterr != nil || res.Err < best.Err)) {
+			best, hbest, besterr = res, h, err
+		}
+	}
+	return best, hbest, besterr
+}
+
+// DiffArr computes the derivative of ff at every point in x, with a fixed step size h.
+// The results are stored in out, which must be the same length as x.
+func DiffArr(dir DerivType, ff gsl.F, x []float64, h float64, out []gsl.Result) error {
+	if len(out) != len(x) {
+		return fmt.Errorf("Incompatible dimensions in DiffArr: x(%d) != out(%d)", len(x), len(out))
+	}
+	var err error
+	for i, x1 := range x {
+		if out[i], err = Diff(dir, ff, x1, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
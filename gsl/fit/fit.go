@@ -0,0 +1,64 @@
+// Package fit wraps the GSL linear and nonlinear least-squares fitting routines.
+//
+// Both kinds of fits accept either diagonal errors (as weights, or folded into the residuals)
+// or a full data covariance matrix. In the latter case, the data are whitened with the
+// Cholesky decomposition of the covariance matrix before fitting.
+package fit
+
+import (
+	"fmt"
+
+	"github.com/npadmana/npgo/gsl/linalg"
+)
+
+// Result holds the results of a fit
+type Result struct {
+	Params []float64      // Best fit parameters
+	Cov    *linalg.Matrix // Covariance matrix of the parameters
+	Chisq  float64        // Chi-squared at the best fit
+	Dof    int            // Degrees of freedom, number of data points - number of parameters
+	Iter   int            // Number of iterations (nonlinear fits only)
+}
+
+// whitener applies L^-1, where C = L L^T is the data covariance matrix.
+// If r = data - model, then L^-1 r has unit covariance.
+type whitener struct {
+	l *linalg.Matrix
+}
+
+func newWhitener(cov *linalg.Matrix) (*whitener, error) {
+	ch, err := linalg.NewCholesky(cov)
+	if err != nil {
+		return nil, fmt.Errorf("Error decomposing covariance matrix : %v", err)
+	}
+	return &whitener{ch.L()}, nil
+}
+
+// vec replaces v by L^-1 v, by forward substitution
+func (w *whitener) vec(v []float64) {
+	n := w.l.Rows
+	for i := 0; i < n; i++ {
+		sum := v[i]
+		row := w.l.Row(i)
+		for j := 0; j < i; j++ {
+			sum -= row[j] * v[j]
+		}
+		v[i] = sum / row[i]
+	}
+}
+
+// mat replaces every column of m by L^-1 applied to it
+func (w *whitener) mat(m *linalg.Matrix) {
+	n, p := w.l.Rows, m.Cols
+	for i := 0; i < n; i++ {
+		row := w.l.Row(i)
+		mi := m.Row(i)
+		for k := 0; k < p; k++ {
+			sum := mi[k]
+			for j := 0; j < i; j++ {
+				sum -= row[j] * m.Data[j*p+k]
+			}
+			mi[k] = sum / row[i]
+		}
+	}
+}
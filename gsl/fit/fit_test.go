@@ -0,0 +1,202 @@
+package fit
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/gsl/linalg"
+	"github.com/npadmana/npgo/nptest"
+)
+
+var eps = nptest.NewEps(1.e-8, 1.e-8)
+
+// Data for a straight line, with a deterministic "noise" term
+func lineData() ([]float64, []float64, []float64) {
+	n := 20
+	x := make([]float64, n)
+	y := make([]float64, n)
+	sig := make([]float64, n)
+	for i := range x {
+		x[i] = float64(i)
+		y[i] = 1.5 + 0.3*x[i] + 0.1*math.Sin(3*x[i])
+		sig[i] = 0.1 * (1 + 0.05*x[i])
+	}
+	return x, y, sig
+}
+
+func TestLinear(t *testing.T) {
+	x := []float64{0, 1, 2, 3}
+	y := []float64{1, 3, 5, 7}
+	res, err := Linear(Poly(x, 1), y, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(1, res.Params[0], "intercept", t)
+	eps.EqFloat64(2, res.Params[1], "slope", t)
+	eps.EqFloat64(0, res.Chisq, "chisq", t)
+	if res.Dof != 2 {
+		t.Errorf("Incorrect degrees of freedom : %d", res.Dof)
+	}
+
+	val, err := res.Eval([]float64{1, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(21, val.Res, "Eval", t)
+
+	if _, err = Linear(Poly(x, 1), y[1:], nil); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+	if _, err = Linear(Poly(x[:2], 3), y[:2], nil); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestLinearCov(t *testing.T) {
+	x, y, sig := lineData()
+	n := len(x)
+	w := make([]float64, n)
+	cov := linalg.NewMatrix(n, n)
+	for i := range w {
+		w[i] = 1 / (sig[i] * sig[i])
+		cov.Set(i, i, sig[i]*sig[i])
+	}
+	X := Poly(x, 1)
+
+	// A diagonal covariance matrix is the same as weights
+	res1, err := Linear(X, y, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res2, err := LinearCov(X, y, cov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range res1.Params {
+		eps.EqFloat64(res1.Params[i], res2.Params[i], "params", t)
+	}
+	for i := range res1.Cov.Data {
+		eps.EqFloat64(res1.Cov.Data[i], res2.Cov.Data[i], "cov", t)
+	}
+	eps.EqFloat64(res1.Chisq, res2.Chisq, "chisq", t)
+
+	// With correlations, check against the generalized least squares solution
+	for i := 0; i < n-1; i++ {
+		c := 0.3 * sig[i] * sig[i+1]
+		cov.Set(i, i+1, c)
+		cov.Set(i+1, i, c)
+	}
+	res2, err = LinearCov(X, y, cov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cinv, _ := linalg.Inverse(cov)
+	cx, _ := linalg.Mul(cinv, X)
+	xtcx, _ := linalg.Mul(X.T(), cx)
+	pcov, _ := linalg.Inverse(xtcx)
+	cy, _ := linalg.MulVec(cinv, y)
+	xtcy, _ := linalg.MulVec(X.T(), cy)
+	params, _ := linalg.MulVec(pcov, xtcy)
+	for i := range params {
+		eps.EqFloat64(params[i], res2.Params[i], "GLS params", t)
+	}
+	for i := range pcov.Data {
+		eps.EqFloat64(pcov.Data[i], res2.Cov.Data[i], "GLS cov", t)
+	}
+}
+
+// Exponential decay model, y = A exp(-lambda t) + b
+type expData struct {
+	t, y, sig []float64
+}
+
+func newExpData() expData {
+	n := 40
+	var d expData
+	for i := 0; i < n; i++ {
+		t1 := float64(i)
+		d.t = append(d.t, t1)
+		d.y = append(d.y, 5*math.Exp(-0.1*t1)+1+0.05*math.Cos(7*t1))
+		d.sig = append(d.sig, 0.1)
+	}
+	return d
+}
+
+func (d expData) resid(p, r []float64) error {
+	for i := range d.t {
+		r[i] = (d.y[i] - (p[0]*math.Exp(-p[1]*d.t[i]) + p[2])) / d.sig[i]
+	}
+	return nil
+}
+
+func (d expData) jac(p []float64, J *linalg.Matrix) error {
+	for i := range d.t {
+		e := math.Exp(-p[1] * d.t[i])
+		J.Set(i, 0, -e/d.sig[i])
+		J.Set(i, 1, p[0]*d.t[i]*e/d.sig[i])
+		J.Set(i, 2, -1/d.sig[i])
+	}
+	return nil
+}
+
+func TestNonlinear(t *testing.T) {
+	d := newExpData()
+	feps := nptest.NewEps(1.e-5, 1.e-5)
+
+	res1, err := Nonlinear(d.resid, d.jac, len(d.t), []float64{1, 0, 0}, gsl.Eps{Abs: 1e-7, Rel: 1e-7}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truth := []float64{5, 0.1, 1}
+	for i := range truth {
+		if math.Abs(res1.Params[i]-truth[i]) > 0.05 {
+			t.Errorf("Parameter %d : expected %f, got %f", i, truth[i], res1.Params[i])
+		}
+	}
+	if res1.Dof != len(d.t)-3 {
+		t.Errorf("Incorrect degrees of freedom : %d", res1.Dof)
+	}
+
+	// Finite difference Jacobian
+	res2, err := Nonlinear(d.resid, nil, len(d.t), []float64{1, 0, 0}, gsl.Eps{Abs: 1e-7, Rel: 1e-7}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range res1.Params {
+		feps.EqFloat64(res1.Params[i], res2.Params[i], "finite difference params", t)
+	}
+
+	// Covariance matrix version
+	n := len(d.t)
+	cov := linalg.NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		cov.Set(i, i, d.sig[i]*d.sig[i])
+	}
+	raw := func(p, r []float64) error {
+		if err := d.resid(p, r); err != nil {
+			return err
+		}
+		for i := range r {
+			r[i] *= d.sig[i]
+		}
+		return nil
+	}
+	res3, err := NonlinearCov(raw, nil, cov, []float64{1, 0, 0}, gsl.Eps{Abs: 1e-7, Rel: 1e-7}, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range res1.Params {
+		feps.EqFloat64(res1.Params[i], res3.Params[i], "covariance params", t)
+	}
+	feps.EqFloat64(res1.Chisq, res3.Chisq, "covariance chisq", t)
+	for i := range res1.Cov.Data {
+		feps.EqFloat64(res1.Cov.Data[i], res3.Cov.Data[i], "covariance cov", t)
+	}
+
+	// Too few iterations
+	_, err = Nonlinear(d.resid, d.jac, len(d.t), []float64{1, 0, 0}, gsl.Eps{Abs: 1e-7, Rel: 1e-7}, 1)
+	if err != gsl.GSL_EMAXITER {
+		t.Errorf("Expected GSL_EMAXITER, got %v", err)
+	}
+}
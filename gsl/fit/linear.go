@@ -0,0 +1,122 @@
+package fit
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_multifit.h>
+
+// wlinear does a weighted linear fit; if w is NULL, all points are equally weighted
+static int wlinear(double *x, size_t n, size_t p, double *w, double *y, double *c, double *cov, double *chisq) {
+	int ret;
+	gsl_matrix_view X = gsl_matrix_view_array(x, n, p);
+	gsl_vector_view Y = gsl_vector_view_array(y, n);
+	gsl_vector_view C = gsl_vector_view_array(c, p);
+	gsl_matrix_view Cov = gsl_matrix_view_array(cov, p, p);
+	gsl_multifit_linear_workspace *work = gsl_multifit_linear_alloc(n, p);
+	if (w == NULL) {
+		ret = gsl_multifit_linear(&X.matrix, &Y.vector, &C.vector, &Cov.matrix, chisq, work);
+	} else {
+		gsl_vector_view W = gsl_vector_view_array(w, n);
+		ret = gsl_multifit_wlinear(&X.matrix, &W.vector, &Y.vector, &C.vector, &Cov.matrix, chisq, work);
+	}
+	gsl_multifit_linear_free(work);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/gsl/linalg"
+)
+
+// Linear fits the model y = X c, where X is the n x p design matrix and c are the
+// parameters. The weights w are typically 1/sigma^2; if w is nil, all points are equally
+// weighted and the parameter covariance is scaled by the scatter of the residuals.
+func Linear(X *linalg.Matrix, y, w []float64) (*Result, error) {
+	n, p := X.Rows, X.Cols
+	if len(y) != n {
+		return nil, fmt.Errorf("Incompatible dimensions in Linear: X(%d x %d), y(%d)", n, p, len(y))
+	}
+	if w != nil && len(w) != n {
+		return nil, fmt.Errorf("Incompatible dimensions in Linear: X(%d x %d), w(%d)", n, p, len(w))
+	}
+	if p == 0 || n < p {
+		return nil, fmt.Errorf("Too few points in Linear: %d points, %d parameters", n, p)
+	}
+
+	res := new(Result)
+	res.Params = make([]float64, p)
+	res.Cov = linalg.NewMatrix(p, p)
+	res.Dof = n - p
+	var wp *C.double
+	if w != nil {
+		wp = (*C.double)(&w[0])
+	}
+	var chisq C.double
+	ret := C.wlinear((*C.double)(&X.Data[0]), C.size_t(n), C.size_t(p), wp, (*C.double)(&y[0]),
+		(*C.double)(&res.Params[0]), (*C.double)(&res.Cov.Data[0]), &chisq)
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	res.Chisq = float64(chisq)
+	return res, nil
+}
+
+// LinearCov fits the model y = X c, with a full covariance matrix cov for the data.
+func LinearCov(X *linalg.Matrix, y []float64, cov *linalg.Matrix) (*Result, error) {
+	n := X.Rows
+	if len(y) != n || cov.Rows != n || cov.Cols != n {
+		return nil, fmt.Errorf("Incompatible dimensions in LinearCov: X(%d x %d), y(%d), cov(%d x %d)",
+			n, X.Cols, len(y), cov.Rows, cov.Cols)
+	}
+	wh, err := newWhitener(cov)
+	if err != nil {
+		return nil, err
+	}
+	Xw := X.Copy()
+	wh.mat(Xw)
+	yw := make([]float64, n)
+	copy(yw, y)
+	wh.vec(yw)
+
+	// The whitened data have unit weights
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = 1
+	}
+	return Linear(Xw, yw, w)
+}
+
+// Poly returns the n x (order+1) design matrix for a polynomial in x, with
+// columns 1, x, x^2, ..., x^order.
+func Poly(x []float64, order int) *linalg.Matrix {
+	X := linalg.NewMatrix(len(x), order+1)
+	for i, x1 := range x {
+		row := X.Row(i)
+		for j := range row {
+			row[j] = math.Pow(x1, float64(j))
+		}
+	}
+	return X
+}
+
+// Eval returns the model X c and its error, propagated from the parameter covariance,
+// for a single row of the design matrix.
+func (r *Result) Eval(row []float64) (gsl.Result, error) {
+	if len(row) != len(r.Params) {
+		return gsl.Result{}, fmt.Errorf("Incompatible dimensions in Eval: row(%d) != params(%d)", len(row), len(r.Params))
+	}
+	y, err := linalg.Dot(row, r.Params)
+	if err != nil {
+		return gsl.Result{}, err
+	}
+	v, err := linalg.QuadForm(row, r.Cov, row)
+	if err != nil {
+		return gsl.Result{}, err
+	}
+	return gsl.Result{Res: y, Err: math.Sqrt(v)}, nil
+}
@@ -0,0 +1,206 @@
+package fit
+
+/*
+#cgo pkg-config: gsl
+
+#include <stdint.h>
+#include <gsl/gsl_blas.h>
+#include <gsl/gsl_multifit_nlin.h>
+
+extern int fitF(gsl_vector *x, void *params, gsl_vector *f);
+extern int fitDF(gsl_vector *x, void *params, gsl_matrix *J);
+
+static int fitF_c(const gsl_vector *x, void *params, gsl_vector *f) {
+	return fitF((gsl_vector *) x, params, f);
+}
+
+static int fitDF_c(const gsl_vector *x, void *params, gsl_matrix *J) {
+	return fitDF((gsl_vector *) x, params, J);
+}
+
+static int fitFDF_c(const gsl_vector *x, void *params, gsl_vector *f, gsl_matrix *J) {
+	int ret = fitF((gsl_vector *) x, params, f);
+	if (ret != 0) {
+		return ret;
+	}
+	return fitDF((gsl_vector *) x, params, J);
+}
+
+// nlfit runs the Levenberg-Marquardt solver from p0, storing the best fit in p0.
+// If hasdf is 0, the Jacobian is computed by finite differences. data is a cgo.Handle
+// for the callback data.
+static int nlfit(uintptr_t data, int hasdf, size_t n, size_t p, double *p0, size_t maxiter,
+		double epsabs, double epsrel, double *cov, double *chisq, size_t *niter) {
+	int ret;
+	size_t iter = 0;
+	double chi;
+	gsl_multifit_function_fdf fdf;
+	gsl_vector_view X = gsl_vector_view_array(p0, p);
+	gsl_matrix_view Cov = gsl_matrix_view_array(cov, p, p);
+	gsl_multifit_fdfsolver *s;
+
+	fdf.f = fitF_c;
+	fdf.df = hasdf ? fitDF_c : NULL;
+	fdf.fdf = hasdf ? fitFDF_c : NULL;
+	fdf.n = n;
+	fdf.p = p;
+	fdf.params = (void *) data;
+
+	s = gsl_multifit_fdfsolver_alloc(gsl_multifit_fdfsolver_lmsder, n, p);
+	ret = gsl_multifit_fdfsolver_set(s, &fdf, &X.vector);
+	if (ret == 0) {
+		do {
+			iter++;
+			ret = gsl_multifit_fdfsolver_iterate(s);
+			if (ret != 0) {
+				break;
+			}
+			ret = gsl_multifit_test_delta(s->dx, s->x, epsabs, epsrel);
+		} while (ret == GSL_CONTINUE && iter < maxiter);
+	}
+	*niter = iter;
+
+	gsl_vector_memcpy(&X.vector, s->x);
+	gsl_multifit_covar(s->J, 0.0, &Cov.matrix);
+	chi = gsl_blas_dnrm2(s->f);
+	*chisq = chi * chi;
+
+	gsl_multifit_fdfsolver_free(s);
+	return ret;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/gsl/linalg"
+)
+
+// Residual computes the residuals r for parameters p. For fits with diagonal errors, these
+// should be (data - model)/sigma; for fits with a covariance matrix, just data - model.
+type Residual func(p, r []float64) error
+
+// Jacobian computes J[i][j] = d r_i / d p_j for parameters p. J is n x len(p).
+type Jacobian func(p []float64, J *linalg.Matrix) error
+
+// nlData is passed through GSL to the callbacks, as a cgo.Handle
+type nlData struct {
+	f   Residual
+	df  Jacobian
+	n   int
+	wh  *whitener
+	err error // Error returned by a callback
+}
+
+// vecSlice returns the storage of a GSL vector as a Go slice, without copying.
+// This requires a unit stride.
+func vecSlice(v *C.gsl_vector) []float64 {
+	return unsafe.Slice((*float64)(unsafe.Pointer(v.data)), int(v.size))
+}
+
+//export fitF
+func fitF(x *C.gsl_vector, data unsafe.Pointer, f *C.gsl_vector) C.int {
+	d := cgo.Handle(uintptr(data)).Value().(*nlData)
+	// The finite difference Jacobian evaluates f into the columns of J, which are strided.
+	var r []float64
+	if f.stride == 1 {
+		r = vecSlice(f)
+	} else {
+		r = make([]float64, int(f.size))
+	}
+	if err := d.f(vecSlice(x), r); err != nil {
+		d.err = err
+		return C.int(gsl.GSL_EBADFUNC)
+	}
+	if d.wh != nil {
+		d.wh.vec(r)
+	}
+	if f.stride != 1 {
+		for i, r1 := range r {
+			C.gsl_vector_set(f, C.size_t(i), C.double(r1))
+		}
+	}
+	return 0
+}
+
+//export fitDF
+func fitDF(x *C.gsl_vector, data unsafe.Pointer, J *C.gsl_matrix) C.int {
+	d := cgo.Handle(uintptr(data)).Value().(*nlData)
+	p := int(x.size)
+	jac := &linalg.Matrix{Rows: d.n, Cols: p, Data: unsafe.Slice((*float64)(unsafe.Pointer(J.data)), d.n*p)}
+	if err := d.df(vecSlice(x), jac); err != nil {
+		d.err = err
+		return C.int(gsl.GSL_EBADFUNC)
+	}
+	if d.wh != nil {
+		d.wh.mat(jac)
+	}
+	return 0
+}
+
+// Nonlinear minimizes the sum of squares of the n residuals computed by f, using
+// the Levenberg-Marquardt algorithm starting from p0. If df is nil, the Jacobian is
+// computed by finite differences.
+//
+// The iteration stops when the change in every parameter is below eps.Abs + eps.Rel |p|,
+// or after maxiter iterations, in which case the current result is returned along with
+// gsl.GSL_EMAXITER.
+func Nonlinear(f Residual, df Jacobian, n int, p0 []float64, eps gsl.Eps, maxiter int) (*Result, error) {
+	return nonlinear(&nlData{f: f, df: df, n: n}, p0, eps, maxiter)
+}
+
+// NonlinearCov is Nonlinear with a full covariance matrix for the data. f (and df)
+// should compute data - model, without any weighting.
+func NonlinearCov(f Residual, df Jacobian, cov *linalg.Matrix, p0 []float64, eps gsl.Eps, maxiter int) (*Result, error) {
+	if cov.Rows != cov.Cols {
+		return nil, fmt.Errorf("Covariance matrix not square : %d x %d", cov.Rows, cov.Cols)
+	}
+	wh, err := newWhitener(cov)
+	if err != nil {
+		return nil, err
+	}
+	return nonlinear(&nlData{f: f, df: df, n: cov.Rows, wh: wh}, p0, eps, maxiter)
+}
+
+func nonlinear(d *nlData, p0 []float64, eps gsl.Eps, maxiter int) (*Result, error) {
+	p := len(p0)
+	if p == 0 || d.n < p {
+		return nil, fmt.Errorf("Too few points in Nonlinear: %d points, %d parameters", d.n, p)
+	}
+	if maxiter < 1 {
+		return nil, fmt.Errorf("maxiter must be positive, got %d", maxiter)
+	}
+
+	res := new(Result)
+	res.Params = make([]float64, p)
+	copy(res.Params, p0)
+	res.Cov = linalg.NewMatrix(p, p)
+	res.Dof = d.n - p
+
+	hasdf := C.int(0)
+	if d.df != nil {
+		hasdf = 1
+	}
+	var chisq C.double
+	var niter C.size_t
+	h := cgo.NewHandle(d)
+	defer h.Delete()
+	ret := C.nlfit(C.uintptr_t(h), hasdf, C.size_t(d.n), C.size_t(p), (*C.double)(&res.Params[0]), C.size_t(maxiter),
+		C.double(eps.Abs), C.double(eps.Rel), (*C.double)(&res.Cov.Data[0]), &chisq, &niter)
+	res.Chisq = float64(chisq)
+	res.Iter = int(niter)
+
+	switch {
+	case d.err != nil:
+		return nil, d.err
+	case ret == C.GSL_CONTINUE:
+		return res, gsl.GSL_EMAXITER
+	case ret != 0:
+		return res, gsl.Errno(ret)
+	}
+	return res, nil
+}
@@ -0,0 +1,14 @@
+package gsl
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_errno.h>
+*/
+import "C"
+
+// The default GSL error handler aborts the program. Turn it off, so that errors are
+// returned to the wrappers, which convert them into an Errno.
+func init() {
+	C.gsl_set_error_handler_off()
+}
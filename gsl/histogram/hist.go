@@ -0,0 +1,239 @@
+// Package histogram wraps the GSL 1D and 2D histograms, and the probability
+// distributions built from them.
+//
+// Histograms can be filled from strided slices (eg. a column of a row-major table), or
+// directly from text files with lineio using the Lines and Lines2D adapters.
+package histogram
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_histogram.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/gsl/random"
+)
+
+// Hist is a 1D histogram
+type Hist struct {
+	h *C.gsl_histogram
+}
+
+// NewUniform returns an empty histogram with n uniform bins spanning [lo, hi)
+func NewUniform(n int, lo, hi float64) (*Hist, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("Need at least one bin, got %d", n)
+	}
+	if hi <= lo {
+		return nil, fmt.Errorf("Empty range in NewUniform: [%f, %f)", lo, hi)
+	}
+	h := new(Hist)
+	h.h = C.gsl_histogram_calloc_uniform(C.size_t(n), C.double(lo), C.double(hi))
+	return h, nil
+}
+
+// New returns an empty histogram with bin edges ranges. There are len(ranges)-1 bins,
+// with bin i spanning [ranges[i], ranges[i+1]).
+func New(ranges []float64) (*Hist, error) {
+	if len(ranges) < 2 {
+		return nil, errors.New("Need at least two bin edges")
+	}
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i] <= ranges[i-1] {
+			return nil, errors.New("Bin edges must be strictly increasing")
+		}
+	}
+	h := new(Hist)
+	h.h = C.gsl_histogram_calloc(C.size_t(len(ranges) - 1))
+	ret := C.gsl_histogram_set_ranges(h.h, (*C.double)(&ranges[0]), C.size_t(len(ranges)))
+	if ret != 0 {
+		h.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return h, nil
+}
+
+// Free frees the histogram
+func (h *Hist) Free() {
+	C.gsl_histogram_free(h.h)
+}
+
+// Bins returns the number of bins
+func (h *Hist) Bins() int {
+	return int(C.gsl_histogram_bins(h.h))
+}
+
+// Accumulate adds weight w to the bin containing x. Returns gsl.GSL_EDOM if x is out of range.
+func (h *Hist) Accumulate(x, w float64) error {
+	ret := C.gsl_histogram_accumulate(h.h, C.double(x), C.double(w))
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	return nil
+}
+
+// Increment adds one to the bin containing x
+func (h *Hist) Increment(x float64) error {
+	return h.Accumulate(x, 1)
+}
+
+// Fill accumulates every element of x, with weights w. x and w are strided as in
+// package stats; if w is nil, the weights are 1. Points outside the histogram are
+// skipped, and their number is returned.
+func (h *Hist) Fill(x []float64, xstride int, w []float64, wstride int) (int, error) {
+	n, err := strided(x, xstride)
+	if err != nil {
+		return 0, err
+	}
+	if err = checkWeights(n, w, wstride); err != nil {
+		return 0, err
+	}
+	nout := 0
+	w1 := 1.0
+	for i := 0; i < n; i++ {
+		if w != nil {
+			w1 = w[i*wstride]
+		}
+		if h.Accumulate(x[i*xstride], w1) != nil {
+			nout++
+		}
+	}
+	return nout, nil
+}
+
+// Find returns the bin containing x
+func (h *Hist) Find(x float64) (int, error) {
+	var i C.size_t
+	ret := C.gsl_histogram_find(h.h, C.double(x), &i)
+	if ret != 0 {
+		return -1, gsl.Errno(ret)
+	}
+	return int(i), nil
+}
+
+// Get returns the contents of bin i
+func (h *Hist) Get(i int) float64 {
+	return float64(C.gsl_histogram_get(h.h, C.size_t(i)))
+}
+
+// Range returns the edges of bin i, [lo, hi)
+func (h *Hist) Range(i int) (float64, float64) {
+	var lo, hi C.double
+	C.gsl_histogram_get_range(h.h, C.size_t(i), &lo, &hi)
+	return float64(lo), float64(hi)
+}
+
+// Values returns a copy of the bin contents
+func (h *Hist) Values() []float64 {
+	arr := make([]float64, h.Bins())
+	for i := range arr {
+		arr[i] = h.Get(i)
+	}
+	return arr
+}
+
+// Edges returns a copy of the bin edges; there are Bins()+1 of them
+func (h *Hist) Edges() []float64 {
+	n := h.Bins()
+	arr := make([]float64, n+1)
+	for i := 0; i < n; i++ {
+		arr[i], arr[i+1] = h.Range(i)
+	}
+	return arr
+}
+
+// Centers returns the bin centers
+func (h *Hist) Centers() []float64 {
+	arr := make([]float64, h.Bins())
+	for i := range arr {
+		lo, hi := h.Range(i)
+		arr[i] = (lo + hi) / 2
+	}
+	return arr
+}
+
+// Sum returns the sum of all the bins
+func (h *Hist) Sum() float64 {
+	return float64(C.gsl_histogram_sum(h.h))
+}
+
+// Mean returns the mean of the histogrammed variable, using the bin centers
+func (h *Hist) Mean() float64 {
+	return float64(C.gsl_histogram_mean(h.h))
+}
+
+// Sigma returns the standard deviation of the histogrammed variable, using the bin centers
+func (h *Hist) Sigma() float64 {
+	return float64(C.gsl_histogram_sigma(h.h))
+}
+
+// Scale multiplies all the bins by s
+func (h *Hist) Scale(s float64) {
+	C.gsl_histogram_scale(h.h, C.double(s))
+}
+
+// Reset zeros all the bins
+func (h *Hist) Reset() {
+	C.gsl_histogram_reset(h.h)
+}
+
+// PDF is a probability distribution built from a 1D histogram
+type PDF struct {
+	p *C.gsl_histogram_pdf
+}
+
+// PDF returns the probability distribution described by the histogram. All the bins
+// must be non-negative.
+func (h *Hist) PDF() (*PDF, error) {
+	p := new(PDF)
+	p.p = C.gsl_histogram_pdf_alloc(C.gsl_histogram_bins(h.h))
+	ret := C.gsl_histogram_pdf_init(p.p, h.h)
+	if ret != 0 {
+		p.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return p, nil
+}
+
+// Free frees the PDF
+func (p *PDF) Free() {
+	C.gsl_histogram_pdf_free(p.p)
+}
+
+// Sample maps a uniform deviate r in [0,1) onto a sample from the distribution.
+// The distribution is uniform within each bin.
+func (p *PDF) Sample(r float64) float64 {
+	return float64(C.gsl_histogram_pdf_sample(p.p, C.double(r)))
+}
+
+// SampleRNG draws a sample from the distribution using rng
+func (p *PDF) SampleRNG(rng *random.RNG) float64 {
+	return p.Sample(rng.Uniform())
+}
+
+func strided(x []float64, stride int) (int, error) {
+	if stride < 1 {
+		return 0, fmt.Errorf("stride must be positive, got %d", stride)
+	}
+	return (len(x) + stride - 1) / stride, nil
+}
+
+func checkWeights(n int, w []float64, wstride int) error {
+	if w == nil {
+		return nil
+	}
+	nw, err := strided(w, wstride)
+	if err != nil {
+		return err
+	}
+	if nw != n {
+		return fmt.Errorf("Incompatible dimensions : %d points, %d weights", n, nw)
+	}
+	return nil
+}
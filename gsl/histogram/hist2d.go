@@ -0,0 +1,218 @@
+package histogram
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_histogram2d.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/gsl/random"
+)
+
+// Hist2D is a 2D histogram
+type Hist2D struct {
+	h *C.gsl_histogram2d
+}
+
+// NewUniform2D returns an empty 2D histogram with nx uniform bins spanning [xlo, xhi)
+// and ny uniform bins spanning [ylo, yhi)
+func NewUniform2D(nx int, xlo, xhi float64, ny int, ylo, yhi float64) (*Hist2D, error) {
+	if nx < 1 || ny < 1 {
+		return nil, fmt.Errorf("Need at least one bin, got %d x %d", nx, ny)
+	}
+	if xhi <= xlo || yhi <= ylo {
+		return nil, fmt.Errorf("Empty range in NewUniform2D: [%f, %f) x [%f, %f)", xlo, xhi, ylo, yhi)
+	}
+	h := new(Hist2D)
+	h.h = C.gsl_histogram2d_calloc_uniform(C.size_t(nx), C.size_t(ny), C.double(xlo), C.double(xhi),
+		C.double(ylo), C.double(yhi))
+	return h, nil
+}
+
+// New2D returns an empty 2D histogram with bin edges xranges and yranges
+func New2D(xranges, yranges []float64) (*Hist2D, error) {
+	for _, r := range [][]float64{xranges, yranges} {
+		if len(r) < 2 {
+			return nil, errors.New("Need at least two bin edges")
+		}
+		for i := 1; i < len(r); i++ {
+			if r[i] <= r[i-1] {
+				return nil, errors.New("Bin edges must be strictly increasing")
+			}
+		}
+	}
+	h := new(Hist2D)
+	h.h = C.gsl_histogram2d_calloc(C.size_t(len(xranges)-1), C.size_t(len(yranges)-1))
+	ret := C.gsl_histogram2d_set_ranges(h.h, (*C.double)(&xranges[0]), C.size_t(len(xranges)),
+		(*C.double)(&yranges[0]), C.size_t(len(yranges)))
+	if ret != 0 {
+		h.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return h, nil
+}
+
+// Free frees the histogram
+func (h *Hist2D) Free() {
+	C.gsl_histogram2d_free(h.h)
+}
+
+// Bins returns the number of bins in x and y
+func (h *Hist2D) Bins() (int, int) {
+	return int(C.gsl_histogram2d_nx(h.h)), int(C.gsl_histogram2d_ny(h.h))
+}
+
+// Accumulate adds weight w to the bin containing (x, y). Returns gsl.GSL_EDOM if
+// (x, y) is out of range.
+func (h *Hist2D) Accumulate(x, y, w float64) error {
+	ret := C.gsl_histogram2d_accumulate(h.h, C.double(x), C.double(y), C.double(w))
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	return nil
+}
+
+// Increment adds one to the bin containing (x, y)
+func (h *Hist2D) Increment(x, y float64) error {
+	return h.Accumulate(x, y, 1)
+}
+
+// Fill accumulates the points (x, y) with weights w, with strides as in Hist.Fill.
+// Points outside the histogram are skipped, and their number is returned.
+func (h *Hist2D) Fill(x []float64, xstride int, y []float64, ystride int, w []float64, wstride int) (int, error) {
+	n, err := strided(x, xstride)
+	if err != nil {
+		return 0, err
+	}
+	ny, err := strided(y, ystride)
+	if err != nil {
+		return 0, err
+	}
+	if ny != n {
+		return 0, fmt.Errorf("Incompatible dimensions : x(%d) != y(%d)", n, ny)
+	}
+	if err = checkWeights(n, w, wstride); err != nil {
+		return 0, err
+	}
+	nout := 0
+	w1 := 1.0
+	for i := 0; i < n; i++ {
+		if w != nil {
+			w1 = w[i*wstride]
+		}
+		if h.Accumulate(x[i*xstride], y[i*ystride], w1) != nil {
+			nout++
+		}
+	}
+	return nout, nil
+}
+
+// Find returns the bin containing (x, y)
+func (h *Hist2D) Find(x, y float64) (int, int, error) {
+	var i, j C.size_t
+	ret := C.gsl_histogram2d_find(h.h, C.double(x), C.double(y), &i, &j)
+	if ret != 0 {
+		return -1, -1, gsl.Errno(ret)
+	}
+	return int(i), int(j), nil
+}
+
+// Get returns the contents of bin (i, j)
+func (h *Hist2D) Get(i, j int) float64 {
+	return float64(C.gsl_histogram2d_get(h.h, C.size_t(i), C.size_t(j)))
+}
+
+// XRange returns the x edges of bins (i, *)
+func (h *Hist2D) XRange(i int) (float64, float64) {
+	var lo, hi C.double
+	C.gsl_histogram2d_get_xrange(h.h, C.size_t(i), &lo, &hi)
+	return float64(lo), float64(hi)
+}
+
+// YRange returns the y edges of bins (*, j)
+func (h *Hist2D) YRange(j int) (float64, float64) {
+	var lo, hi C.double
+	C.gsl_histogram2d_get_yrange(h.h, C.size_t(j), &lo, &hi)
+	return float64(lo), float64(hi)
+}
+
+// Sum returns the sum of all the bins
+func (h *Hist2D) Sum() float64 {
+	return float64(C.gsl_histogram2d_sum(h.h))
+}
+
+// XMean returns the mean of x, using the bin centers
+func (h *Hist2D) XMean() float64 {
+	return float64(C.gsl_histogram2d_xmean(h.h))
+}
+
+// YMean returns the mean of y, using the bin centers
+func (h *Hist2D) YMean() float64 {
+	return float64(C.gsl_histogram2d_ymean(h.h))
+}
+
+// XSigma returns the standard deviation of x, using the bin centers
+func (h *Hist2D) XSigma() float64 {
+	return float64(C.gsl_histogram2d_xsigma(h.h))
+}
+
+// YSigma returns the standard deviation of y, using the bin centers
+func (h *Hist2D) YSigma() float64 {
+	return float64(C.gsl_histogram2d_ysigma(h.h))
+}
+
+// Cov returns the covariance of x and y, using the bin centers
+func (h *Hist2D) Cov() float64 {
+	return float64(C.gsl_histogram2d_cov(h.h))
+}
+
+// Scale multiplies all the bins by s
+func (h *Hist2D) Scale(s float64) {
+	C.gsl_histogram2d_scale(h.h, C.double(s))
+}
+
+// Reset zeros all the bins
+func (h *Hist2D) Reset() {
+	C.gsl_histogram2d_reset(h.h)
+}
+
+// PDF2D is a probability distribution built from a 2D histogram
+type PDF2D struct {
+	p *C.gsl_histogram2d_pdf
+}
+
+// PDF returns the probability distribution described by the histogram. All the bins
+// must be non-negative.
+func (h *Hist2D) PDF() (*PDF2D, error) {
+	p := new(PDF2D)
+	p.p = C.gsl_histogram2d_pdf_alloc(C.gsl_histogram2d_nx(h.h), C.gsl_histogram2d_ny(h.h))
+	ret := C.gsl_histogram2d_pdf_init(p.p, h.h)
+	if ret != 0 {
+		p.Free()
+		return nil, gsl.Errno(ret)
+	}
+	return p, nil
+}
+
+// Free frees the PDF
+func (p *PDF2D) Free() {
+	C.gsl_histogram2d_pdf_free(p.p)
+}
+
+// Sample maps two uniform deviates r1, r2 in [0,1) onto a sample (x, y) from the distribution
+func (p *PDF2D) Sample(r1, r2 float64) (float64, float64) {
+	var x, y C.double
+	C.gsl_histogram2d_pdf_sample(p.p, C.double(r1), C.double(r2), &x, &y)
+	return float64(x), float64(y)
+}
+
+// SampleRNG draws a sample from the distribution using rng
+func (p *PDF2D) SampleRNG(rng *random.RNG) (float64, float64) {
+	return p.Sample(rng.Uniform(), rng.Uniform())
+}
@@ -0,0 +1,168 @@
+package histogram
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/npadmana/npgo/gsl"
+	"github.com/npadmana/npgo/lineio"
+	"github.com/npadmana/npgo/nptest"
+)
+
+var eps = nptest.NewEps(1.e-10, 1.e-10)
+
+func TestHist(t *testing.T) {
+	h, err := NewUniform(4, 0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Free()
+
+	// Every other element of a two-column table, with weights in the second column
+	data := []float64{0.5, 1, 1.5, 2, 1.7, 1, 3.2, 4, 10, 1}
+	nout, err := h.Fill(data, 2, data[1:], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nout != 1 {
+		t.Errorf("Expected 1 point outside, got %d", nout)
+	}
+	expected := []float64{1, 3, 0, 4}
+	for i, v := range h.Values() {
+		eps.EqFloat64(expected[i], v, "bin", t)
+	}
+	eps.EqFloat64(8, h.Sum(), "Sum", t)
+	eps.EqFloat64((0.5+3*1.5+4*3.5)/8, h.Mean(), "Mean", t)
+
+	lo, hi := h.Range(2)
+	eps.EqFloat64(2, lo, "Range lo", t)
+	eps.EqFloat64(3, hi, "Range hi", t)
+	if i, _ := h.Find(3.7); i != 3 {
+		t.Errorf("Find : expected 3, got %d", i)
+	}
+	if err = h.Increment(-1); err != gsl.GSL_EDOM {
+		t.Errorf("Expected GSL_EDOM, got %v", err)
+	}
+	if _, err = h.Fill(data, 2, data, 1); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestHistRanges(t *testing.T) {
+	edges := []float64{0, 1, 10, 100}
+	h, err := New(edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Free()
+	if h.Bins() != 3 {
+		t.Errorf("Expected 3 bins, got %d", h.Bins())
+	}
+	for i, e := range h.Edges() {
+		eps.EqFloat64(edges[i], e, "Edges", t)
+	}
+	h.Increment(50)
+	eps.EqFloat64(1, h.Get(2), "Get", t)
+
+	if _, err = New([]float64{0, 2, 1}); err == nil {
+		t.Error("Expected an error for unordered edges, none reported")
+	}
+}
+
+func TestPDF(t *testing.T) {
+	h, _ := New([]float64{0, 1, 3})
+	defer h.Free()
+	h.Accumulate(0.5, 1)
+	h.Accumulate(2, 3)
+	p, err := h.PDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	// The first quarter of the probability is in [0,1), the rest in [1,3)
+	eps.EqFloat64(0.5, p.Sample(0.125), "Sample", t)
+	eps.EqFloat64(1, p.Sample(0.25), "Sample", t)
+	eps.EqFloat64(2, p.Sample(0.625), "Sample", t)
+
+	h.Accumulate(0.5, -10)
+	if _, err = h.PDF(); err == nil {
+		t.Error("Expected an error for negative bins, none reported")
+	}
+}
+
+func TestHist2D(t *testing.T) {
+	h, err := NewUniform2D(2, 0, 2, 2, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Free()
+	x := []float64{0.5, 1.5, 1.5, 5}
+	y := []float64{0.5, 0.5, 1.5, 0.5}
+	nout, err := h.Fill(x, 1, y, 1, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nout != 1 {
+		t.Errorf("Expected 1 point outside, got %d", nout)
+	}
+	eps.EqFloat64(1, h.Get(1, 0), "Get", t)
+	eps.EqFloat64(0, h.Get(0, 1), "Get", t)
+	eps.EqFloat64(3, h.Sum(), "Sum", t)
+	eps.EqFloat64(3.5/3, h.XMean(), "XMean", t)
+	eps.EqFloat64(2.5/3, h.YMean(), "YMean", t)
+
+	p, err := h.PDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Free()
+	px, py := p.Sample(0.1, 0.5)
+	if px < 0 || px >= 1 || py < 0 || py >= 1 {
+		t.Errorf("Sample (%f, %f) outside the first bin", px, py)
+	}
+	if math.IsNaN(h.Cov()) {
+		t.Error("Cov is NaN")
+	}
+}
+
+func TestLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "histogram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fn := filepath.Join(dir, "data.dat")
+	text := "# x y w\n0.5 0.5 2\n1.5 0.5 1\n1.5 1.5 1 # comment\n7 0.5 1\n"
+	if err = ioutil.WriteFile(fn, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h, _ := NewUniform(2, 0, 2)
+	defer h.Free()
+	l := &Lines{H: h, X: 0, W: 2}
+	if err = lineio.Read(fn, l); err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(2, h.Get(0), "Lines", t)
+	eps.EqFloat64(2, h.Get(1), "Lines", t)
+	if l.Outside != 1 {
+		t.Errorf("Expected 1 point outside, got %d", l.Outside)
+	}
+
+	h2, _ := NewUniform2D(2, 0, 2, 2, 0, 2)
+	defer h2.Free()
+	l2 := &Lines2D{H: h2, X: 0, Y: 1, W: -1}
+	if err = lineio.Read(fn, l2); err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(1, h2.Get(1, 1), "Lines2D", t)
+	eps.EqFloat64(3, h2.Sum(), "Lines2D", t)
+
+	l.X = 5
+	if err = lineio.Read(fn, l); err == nil {
+		t.Error("Expected an error for a missing column, none reported")
+	}
+}
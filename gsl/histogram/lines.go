@@ -0,0 +1,75 @@
+package histogram
+
+import (
+	"fmt"
+
+	"github.com/npadmana/npgo/lineio"
+)
+
+// Lines fills a histogram from a text file, and satisfies lineio.LineIOType.
+// Each line is split on spaces, and column X is accumulated with the weight in column W.
+// Columns are counted from 0; set W < 0 for unit weights. Points outside the histogram
+// are counted in Outside.
+type Lines struct {
+	H       *Hist
+	X, W    int
+	Outside int
+	buf     []float64
+}
+
+// Add parses a single line
+func (l *Lines) Add(b []byte) error {
+	l.buf = l.buf[:0]
+	if err := lineio.ParseToFloat64Arr(b, []byte{' '}, &l.buf, true); err != nil {
+		return err
+	}
+	x, w, err := columns(l.buf, l.X, l.W)
+	if err != nil {
+		return err
+	}
+	if l.H.Accumulate(x, w) != nil {
+		l.Outside++
+	}
+	return nil
+}
+
+// Lines2D fills a 2D histogram from a text file, as for Lines.
+type Lines2D struct {
+	H       *Hist2D
+	X, Y, W int
+	Outside int
+	buf     []float64
+}
+
+// Add parses a single line
+func (l *Lines2D) Add(b []byte) error {
+	l.buf = l.buf[:0]
+	if err := lineio.ParseToFloat64Arr(b, []byte{' '}, &l.buf, true); err != nil {
+		return err
+	}
+	x, w, err := columns(l.buf, l.X, l.W)
+	if err != nil {
+		return err
+	}
+	if l.Y < 0 || l.Y >= len(l.buf) {
+		return fmt.Errorf("Column %d not found in line with %d columns", l.Y, len(l.buf))
+	}
+	if l.H.Accumulate(x, l.buf[l.Y], w) != nil {
+		l.Outside++
+	}
+	return nil
+}
+
+// columns returns the value in column x, and the weight in column w (1 if w < 0)
+func columns(arr []float64, x, w int) (float64, float64, error) {
+	if x < 0 || x >= len(arr) {
+		return 0, 0, fmt.Errorf("Column %d not found in line with %d columns", x, len(arr))
+	}
+	if w < 0 {
+		return arr[x], 1, nil
+	}
+	if w >= len(arr) {
+		return 0, 0, fmt.Errorf("Column %d not found in line with %d columns", w, len(arr))
+	}
+	return arr[x], arr[w], nil
+}
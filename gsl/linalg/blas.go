@@ -0,0 +1,100 @@
+package linalg
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_blas.h>
+
+// dgemm computes C = alpha op(A) op(B) + beta C
+static int dgemm(int transA, int transB, double alpha, double *a, size_t ar, size_t ac,
+		double *b, size_t br, size_t bc, double beta, double *c, size_t cr, size_t cc) {
+	gsl_matrix_view A = gsl_matrix_view_array(a, ar, ac);
+	gsl_matrix_view B = gsl_matrix_view_array(b, br, bc);
+	gsl_matrix_view C = gsl_matrix_view_array(c, cr, cc);
+	return gsl_blas_dgemm(transA ? CblasTrans : CblasNoTrans, transB ? CblasTrans : CblasNoTrans,
+		alpha, &A.matrix, &B.matrix, beta, &C.matrix);
+}
+
+// dgemv computes y = alpha A x + beta y
+static int dgemv(double alpha, double *a, size_t ar, size_t ac, double *x, double beta, double *y) {
+	gsl_matrix_view A = gsl_matrix_view_array(a, ar, ac);
+	gsl_vector_view X = gsl_vector_view_array(x, ac);
+	gsl_vector_view Y = gsl_vector_view_array(y, ar);
+	return gsl_blas_dgemv(CblasNoTrans, alpha, &A.matrix, &X.vector, beta, &Y.vector);
+}
+
+static int ddot(double *x, double *y, size_t n, double *res) {
+	gsl_vector_view X = gsl_vector_view_array(x, n);
+	gsl_vector_view Y = gsl_vector_view_array(y, n);
+	return gsl_blas_ddot(&X.vector, &Y.vector, res);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Mul returns the matrix product a b
+func Mul(a, b *Matrix) (*Matrix, error) {
+	if a.Cols != b.Rows {
+		return nil, fmt.Errorf("Incompatible dimensions in Mul: %d x %d times %d x %d", a.Rows, a.Cols, b.Rows, b.Cols)
+	}
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	c := NewMatrix(a.Rows, b.Cols)
+	ret := C.dgemm(0, 0, 1, a.ptr(), C.size_t(a.Rows), C.size_t(a.Cols), b.ptr(), C.size_t(b.Rows), C.size_t(b.Cols),
+		0, c.ptr(), C.size_t(c.Rows), C.size_t(c.Cols))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return c, nil
+}
+
+// MulVec returns the matrix-vector product a x
+func MulVec(a *Matrix, x []float64) ([]float64, error) {
+	if a.Cols != len(x) {
+		return nil, fmt.Errorf("Incompatible dimensions in MulVec: %d x %d times %d", a.Rows, a.Cols, len(x))
+	}
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	y := make([]float64, a.Rows)
+	ret := C.dgemv(1, a.ptr(), C.size_t(a.Rows), C.size_t(a.Cols), vptr(x), 0, vptr(y))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return y, nil
+}
+
+// Dot returns the inner product of x and y
+func Dot(x, y []float64) (float64, error) {
+	if len(x) != len(y) {
+		return 0, fmt.Errorf("Incompatible dimensions in Dot: x(%d) != y(%d)", len(x), len(y))
+	}
+	if len(x) == 0 {
+		return 0, nil
+	}
+	var res C.double
+	ret := C.ddot(vptr(x), vptr(y), C.size_t(len(x)), &res)
+	if ret != 0 {
+		return 0, gsl.Errno(ret)
+	}
+	return float64(res), nil
+}
+
+// QuadForm returns x^T a y. With a an inverse covariance matrix and x = y
+// the residuals, this is the chi-squared.
+func QuadForm(x []float64, a *Matrix, y []float64) (float64, error) {
+	ay, err := MulVec(a, y)
+	if err != nil {
+		return 0, err
+	}
+	return Dot(x, ay)
+}
@@ -0,0 +1,376 @@
+package linalg
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_linalg.h>
+
+static int lu_decomp(double *a, size_t n, size_t *perm, int *signum) {
+	gsl_matrix_view A = gsl_matrix_view_array(a, n, n);
+	gsl_permutation p;
+	p.size = n;
+	p.data = perm;
+	return gsl_linalg_LU_decomp(&A.matrix, &p, signum);
+}
+
+static int lu_solve(double *lu, size_t n, size_t *perm, double *b, double *x) {
+	gsl_matrix_view A = gsl_matrix_view_array(lu, n, n);
+	gsl_vector_view B = gsl_vector_view_array(b, n);
+	gsl_vector_view X = gsl_vector_view_array(x, n);
+	gsl_permutation p;
+	p.size = n;
+	p.data = perm;
+	return gsl_linalg_LU_solve(&A.matrix, &p, &B.vector, &X.vector);
+}
+
+static int lu_invert(double *lu, size_t n, size_t *perm, double *inv) {
+	gsl_matrix_view A = gsl_matrix_view_array(lu, n, n);
+	gsl_matrix_view I = gsl_matrix_view_array(inv, n, n);
+	gsl_permutation p;
+	p.size = n;
+	p.data = perm;
+	return gsl_linalg_LU_invert(&A.matrix, &p, &I.matrix);
+}
+
+static double lu_det(double *lu, size_t n, int signum) {
+	gsl_matrix_view A = gsl_matrix_view_array(lu, n, n);
+	return gsl_linalg_LU_det(&A.matrix, signum);
+}
+
+static double lu_lndet(double *lu, size_t n) {
+	gsl_matrix_view A = gsl_matrix_view_array(lu, n, n);
+	return gsl_linalg_LU_lndet(&A.matrix);
+}
+
+static int lu_sgndet(double *lu, size_t n, int signum) {
+	gsl_matrix_view A = gsl_matrix_view_array(lu, n, n);
+	return gsl_linalg_LU_sgndet(&A.matrix, signum);
+}
+
+static int chol_decomp(double *a, size_t n) {
+	gsl_matrix_view A = gsl_matrix_view_array(a, n, n);
+	return gsl_linalg_cholesky_decomp(&A.matrix);
+}
+
+static int chol_solve(double *l, size_t n, double *b, double *x) {
+	gsl_matrix_view A = gsl_matrix_view_array(l, n, n);
+	gsl_vector_view B = gsl_vector_view_array(b, n);
+	gsl_vector_view X = gsl_vector_view_array(x, n);
+	return gsl_linalg_cholesky_solve(&A.matrix, &B.vector, &X.vector);
+}
+
+static int chol_invert(double *l, size_t n) {
+	gsl_matrix_view A = gsl_matrix_view_array(l, n, n);
+	return gsl_linalg_cholesky_invert(&A.matrix);
+}
+
+static int qr_decomp(double *a, size_t m, size_t n, double *tau) {
+	gsl_matrix_view A = gsl_matrix_view_array(a, m, n);
+	gsl_vector_view T = gsl_vector_view_array(tau, m < n ? m : n);
+	return gsl_linalg_QR_decomp(&A.matrix, &T.vector);
+}
+
+static int qr_lssolve(double *qr, size_t m, size_t n, double *tau, double *b, double *x, double *resid) {
+	gsl_matrix_view A = gsl_matrix_view_array(qr, m, n);
+	gsl_vector_view T = gsl_vector_view_array(tau, n);
+	gsl_vector_view B = gsl_vector_view_array(b, m);
+	gsl_vector_view X = gsl_vector_view_array(x, n);
+	gsl_vector_view R = gsl_vector_view_array(resid, m);
+	return gsl_linalg_QR_lssolve(&A.matrix, &T.vector, &B.vector, &X.vector, &R.vector);
+}
+
+static int sv_decomp(double *a, size_t m, size_t n, double *v, double *s) {
+	int ret;
+	gsl_matrix_view A = gsl_matrix_view_array(a, m, n);
+	gsl_matrix_view V = gsl_matrix_view_array(v, n, n);
+	gsl_vector_view S = gsl_vector_view_array(s, n);
+	gsl_vector *work = gsl_vector_alloc(n);
+	ret = gsl_linalg_SV_decomp(&A.matrix, &V.matrix, &S.vector, work);
+	gsl_vector_free(work);
+	return ret;
+}
+
+static int sv_solve(double *u, size_t m, size_t n, double *v, double *s, double *b, double *x) {
+	gsl_matrix_view U = gsl_matrix_view_array(u, m, n);
+	gsl_matrix_view V = gsl_matrix_view_array(v, n, n);
+	gsl_vector_view S = gsl_vector_view_array(s, n);
+	gsl_vector_view B = gsl_vector_view_array(b, m);
+	gsl_vector_view X = gsl_vector_view_array(x, n);
+	return gsl_linalg_SV_solve(&U.matrix, &V.matrix, &S.vector, &B.vector, &X.vector);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+func checkRHS(n int, b []float64) error {
+	if len(b) != n {
+		return fmt.Errorf("Incompatible dimensions: matrix(%d) != b(%d)", n, len(b))
+	}
+	return nil
+}
+
+// LU is the LU decomposition of a square matrix, PA = LU
+type LU struct {
+	lu     *Matrix
+	perm   []C.size_t
+	signum C.int
+}
+
+// NewLU returns the LU decomposition of a
+func NewLU(a *Matrix) (*LU, error) {
+	if err := a.checkSquare(); err != nil {
+		return nil, err
+	}
+	lu := new(LU)
+	lu.lu = a.Copy()
+	lu.perm = make([]C.size_t, a.Rows)
+	ret := C.lu_decomp(lu.lu.ptr(), C.size_t(a.Rows), &lu.perm[0], &lu.signum)
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return lu, nil
+}
+
+// Solve solves a x = b
+func (lu *LU) Solve(b []float64) ([]float64, error) {
+	n := lu.lu.Rows
+	if err := checkRHS(n, b); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	ret := C.lu_solve(lu.lu.ptr(), C.size_t(n), &lu.perm[0], vptr(b), vptr(x))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return x, nil
+}
+
+// Inverse returns the inverse of a
+func (lu *LU) Inverse() (*Matrix, error) {
+	n := lu.lu.Rows
+	inv := NewMatrix(n, n)
+	ret := C.lu_invert(lu.lu.ptr(), C.size_t(n), &lu.perm[0], inv.ptr())
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return inv, nil
+}
+
+// Det returns the determinant of a
+func (lu *LU) Det() float64 {
+	return float64(C.lu_det(lu.lu.ptr(), C.size_t(lu.lu.Rows), lu.signum))
+}
+
+// LogDet returns log|det a| and the sign of the determinant
+func (lu *LU) LogDet() (float64, int) {
+	n := C.size_t(lu.lu.Rows)
+	return float64(C.lu_lndet(lu.lu.ptr(), n)), int(C.lu_sgndet(lu.lu.ptr(), n, lu.signum))
+}
+
+// Cholesky is the Cholesky decomposition of a symmetric, positive-definite matrix, A = L L^T
+type Cholesky struct {
+	l *Matrix
+}
+
+// NewCholesky returns the Cholesky decomposition of a. Only the lower triangle of
+// a is used. Returns an error if a is not positive-definite.
+func NewCholesky(a *Matrix) (*Cholesky, error) {
+	if err := a.checkSquare(); err != nil {
+		return nil, err
+	}
+	ch := new(Cholesky)
+	ch.l = a.Copy()
+	ret := C.chol_decomp(ch.l.ptr(), C.size_t(a.Rows))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return ch, nil
+}
+
+// L returns the lower triangular factor
+func (ch *Cholesky) L() *Matrix {
+	n := ch.l.Rows
+	l := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			l.Data[i*n+j] = ch.l.Data[i*n+j]
+		}
+	}
+	return l
+}
+
+// Solve solves a x = b
+func (ch *Cholesky) Solve(b []float64) ([]float64, error) {
+	n := ch.l.Rows
+	if err := checkRHS(n, b); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	ret := C.chol_solve(ch.l.ptr(), C.size_t(n), vptr(b), vptr(x))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return x, nil
+}
+
+// Inverse returns the inverse of a
+func (ch *Cholesky) Inverse() (*Matrix, error) {
+	inv := ch.l.Copy()
+	ret := C.chol_invert(inv.ptr(), C.size_t(inv.Rows))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return inv, nil
+}
+
+// LogDet returns log(det a). The determinant of a positive-definite matrix is positive.
+func (ch *Cholesky) LogDet() float64 {
+	n := ch.l.Rows
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		sum += math.Log(ch.l.Data[i*n+i])
+	}
+	return 2 * sum
+}
+
+// QR is the QR decomposition of an m x n matrix, A = QR
+type QR struct {
+	qr  *Matrix
+	tau []float64
+}
+
+// NewQR returns the QR decomposition of a
+func NewQR(a *Matrix) (*QR, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	qr := new(QR)
+	qr.qr = a.Copy()
+	if a.Rows < a.Cols {
+		qr.tau = make([]float64, a.Rows)
+	} else {
+		qr.tau = make([]float64, a.Cols)
+	}
+	ret := C.qr_decomp(qr.qr.ptr(), C.size_t(a.Rows), C.size_t(a.Cols), vptr(qr.tau))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return qr, nil
+}
+
+// LeastSquares finds x that minimizes ||a x - b|| for m >= n, returning x and the
+// residual b - a x. For square a, this solves a x = b.
+func (qr *QR) LeastSquares(b []float64) ([]float64, []float64, error) {
+	m, n := qr.qr.Rows, qr.qr.Cols
+	if m < n {
+		return nil, nil, fmt.Errorf("Underdetermined system : %d x %d", m, n)
+	}
+	if err := checkRHS(m, b); err != nil {
+		return nil, nil, err
+	}
+	x := make([]float64, n)
+	resid := make([]float64, m)
+	ret := C.qr_lssolve(qr.qr.ptr(), C.size_t(m), C.size_t(n), vptr(qr.tau), vptr(b), vptr(x), vptr(resid))
+	if ret != 0 {
+		return nil, nil, gsl.Errno(ret)
+	}
+	return x, resid, nil
+}
+
+// SVD is the singular value decomposition of an m x n matrix, A = U diag(S) V^T, with m >= n.
+// U is m x n, and V is n x n.
+type SVD struct {
+	U, V *Matrix
+	S    []float64 // Singular values, in decreasing order
+}
+
+// NewSVD returns the singular value decomposition of a, which must have at least as
+// many rows as columns.
+func NewSVD(a *Matrix) (*SVD, error) {
+	if err := a.check(); err != nil {
+		return nil, err
+	}
+	m, n := a.Rows, a.Cols
+	if m < n {
+		return nil, fmt.Errorf("SVD requires rows >= cols : %d x %d", m, n)
+	}
+	sv := new(SVD)
+	sv.U = a.Copy()
+	sv.V = NewMatrix(n, n)
+	sv.S = make([]float64, n)
+	ret := C.sv_decomp(sv.U.ptr(), C.size_t(m), C.size_t(n), sv.V.ptr(), vptr(sv.S))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return sv, nil
+}
+
+// Solve finds the least-squares solution to a x = b. Singular values that are zero are
+// dropped; zero out small singular values in S before calling this to regularize the
+// solution.
+func (sv *SVD) Solve(b []float64) ([]float64, error) {
+	m, n := sv.U.Rows, sv.U.Cols
+	if err := sv.U.check(); err != nil {
+		return nil, err
+	}
+	if err := sv.V.check(); err != nil {
+		return nil, err
+	}
+	if sv.V.Rows != n || sv.V.Cols != n || len(sv.S) != n {
+		return nil, fmt.Errorf("Incompatible dimensions in SVD: U %d x %d, V %d x %d, S(%d)", m, n, sv.V.Rows, sv.V.Cols, len(sv.S))
+	}
+	if err := checkRHS(m, b); err != nil {
+		return nil, err
+	}
+	x := make([]float64, n)
+	ret := C.sv_solve(sv.U.ptr(), C.size_t(m), C.size_t(n), sv.V.ptr(), vptr(sv.S), vptr(b), vptr(x))
+	if ret != 0 {
+		return nil, gsl.Errno(ret)
+	}
+	return x, nil
+}
+
+// Inverse returns the inverse of a, using an LU decomposition
+func Inverse(a *Matrix) (*Matrix, error) {
+	lu, err := NewLU(a)
+	if err != nil {
+		return nil, err
+	}
+	return lu.Inverse()
+}
+
+// LogDet returns log|det a| and the sign of the determinant, using an LU decomposition
+func LogDet(a *Matrix) (float64, int, error) {
+	lu, err := NewLU(a)
+	if err != nil {
+		return 0, 0, err
+	}
+	ld, sgn := lu.LogDet()
+	return ld, sgn, nil
+}
+
+// HartlapInverse returns the inverse of a covariance matrix estimated from nmocks
+// mock catalogs, including the Hartlap et al. (2007) correction factor
+// (nmocks - p - 2)/(nmocks - 1), where p is the dimension of the matrix.
+func HartlapInverse(cov *Matrix, nmocks int) (*Matrix, error) {
+	p := cov.Rows
+	if nmocks-p-2 <= 0 {
+		return nil, fmt.Errorf("Too few mocks (%d) for a %d x %d covariance matrix", nmocks, p, p)
+	}
+	ch, err := NewCholesky(cov)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := ch.Inverse()
+	if err != nil {
+		return nil, err
+	}
+	inv.Scale(float64(nmocks-p-2) / float64(nmocks-1))
+	return inv, nil
+}
@@ -0,0 +1,46 @@
+package linalg
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_eigen.h>
+
+// eigen_symmv destroys a, returning eigenvalues in ascending order and the
+// eigenvectors in the columns of evec
+static int eigen_symmv(double *a, size_t n, double *eval, double *evec) {
+	int ret;
+	gsl_matrix_view A = gsl_matrix_view_array(a, n, n);
+	gsl_vector_view E = gsl_vector_view_array(eval, n);
+	gsl_matrix_view V = gsl_matrix_view_array(evec, n, n);
+	gsl_eigen_symmv_workspace *w = gsl_eigen_symmv_alloc(n);
+	ret = gsl_eigen_symmv(&A.matrix, &E.vector, &V.matrix, w);
+	gsl_eigen_symmv_free(w);
+	if (ret != 0) {
+		return ret;
+	}
+	return gsl_eigen_symmv_sort(&E.vector, &V.matrix, GSL_EIGEN_SORT_VAL_ASC);
+}
+*/
+import "C"
+
+import (
+	"github.com/npadmana/npgo/gsl"
+)
+
+// SymmEigen returns the eigenvalues of the symmetric matrix a in ascending order, and
+// the corresponding normalized eigenvectors as the columns of a matrix.
+// Only the lower triangle of a is used.
+func SymmEigen(a *Matrix) ([]float64, *Matrix, error) {
+	if err := a.checkSquare(); err != nil {
+		return nil, nil, err
+	}
+	n := a.Rows
+	tmp := a.Copy()
+	eval := make([]float64, n)
+	evec := NewMatrix(n, n)
+	ret := C.eigen_symmv(tmp.ptr(), C.size_t(n), vptr(eval), evec.ptr())
+	if ret != 0 {
+		return nil, nil, gsl.Errno(ret)
+	}
+	return eval, evec, nil
+}
@@ -0,0 +1,285 @@
+package linalg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npadmana/npgo/nptest"
+)
+
+var eps = nptest.NewEps(1.e-12, 1.e-10)
+
+// A symmetric, positive-definite matrix
+func testMatrix() *Matrix {
+	a, _ := View(3, 3, []float64{4, 1, 0.5, 1, 3, 0.2, 0.5, 0.2, 2})
+	return a
+}
+
+func checkIdentity(m *Matrix, s string, t *testing.T) {
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			if i == j {
+				eps.EqFloat64(1, m.At(i, j), s, t)
+			} else {
+				eps.EqFloat64(0, m.At(i, j), s, t)
+			}
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, 6}
+	m, err := View(2, 3, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Set(1, 2, 10)
+	if data[5] != 10 {
+		t.Error("View does not share storage")
+	}
+	eps.EqFloat64(2, m.At(0, 1), "At", t)
+	mt := m.T()
+	eps.EqFloat64(2, mt.At(1, 0), "T", t)
+
+	if _, err = View(2, 2, data); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestBadMatrix(t *testing.T) {
+	bad := &Matrix{3, 3, make([]float64, 4)}
+	a := testMatrix()
+	if _, err := Mul(a, bad); err == nil {
+		t.Error("Mul : expected an error, none reported")
+	}
+	if _, err := MulVec(bad, []float64{1, 2, 3}); err == nil {
+		t.Error("MulVec : expected an error, none reported")
+	}
+	if _, err := NewLU(bad); err == nil {
+		t.Error("NewLU : expected an error, none reported")
+	}
+	if _, err := NewCholesky(bad); err == nil {
+		t.Error("NewCholesky : expected an error, none reported")
+	}
+	if _, err := NewQR(bad); err == nil {
+		t.Error("NewQR : expected an error, none reported")
+	}
+	if _, err := NewSVD(bad); err == nil {
+		t.Error("NewSVD : expected an error, none reported")
+	}
+	if _, _, err := SymmEigen(bad); err == nil {
+		t.Error("SymmEigen : expected an error, none reported")
+	}
+	if _, err := Inverse(bad); err == nil {
+		t.Error("Inverse : expected an error, none reported")
+	}
+	if _, err := Inverse(&Matrix{-1, -1, nil}); err == nil {
+		t.Error("Inverse : expected an error, none reported")
+	}
+
+	sv, err := NewSVD(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv.S = sv.S[:2]
+	if _, err := sv.Solve([]float64{1, 2, 3}); err == nil {
+		t.Error("SVD Solve : expected an error, none reported")
+	}
+}
+
+func TestMul(t *testing.T) {
+	a, _ := View(2, 3, []float64{1, 2, 3, 4, 5, 6})
+	b, _ := View(3, 2, []float64{7, 8, 9, 10, 11, 12})
+	c, err := Mul(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truth := []float64{58, 64, 139, 154}
+	for i := range truth {
+		eps.EqFloat64(truth[i], c.Data[i], "Mul", t)
+	}
+
+	y, err := MulVec(a, []float64{1, 0, -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(-2, y[0], "MulVec", t)
+	eps.EqFloat64(-2, y[1], "MulVec", t)
+
+	if _, err = Mul(a, a); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+
+	chi2, err := QuadForm([]float64{1, 2}, Identity(2), []float64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(5, chi2, "QuadForm", t)
+}
+
+func TestLU(t *testing.T) {
+	a := testMatrix()
+	lu, err := NewLU(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := lu.Solve([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := MulVec(a, x)
+	for i, b1 := range []float64{1, 2, 3} {
+		eps.EqFloat64(b1, b[i], "LU Solve", t)
+	}
+
+	inv, err := lu.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, _ := Mul(a, inv)
+	checkIdentity(prod, "LU Inverse", t)
+
+	det := 4*(3*2-0.2*0.2) - 1*(1*2-0.2*0.5) + 0.5*(1*0.2-3*0.5)
+	eps.EqFloat64(det, lu.Det(), "LU Det", t)
+	ld, sgn := lu.LogDet()
+	eps.EqFloat64(math.Log(det), ld, "LU LogDet", t)
+	if sgn != 1 {
+		t.Errorf("Incorrect sign of determinant : %d", sgn)
+	}
+
+	if _, err = NewLU(NewMatrix(2, 3)); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+
+	// Singular matrix
+	s, _ := View(2, 2, []float64{1, 2, 2, 4})
+	lu, err = NewLU(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = lu.Inverse(); err == nil {
+		t.Error("Expected an error for a singular matrix, none reported")
+	}
+}
+
+func TestCholesky(t *testing.T) {
+	a := testMatrix()
+	ch, err := NewCholesky(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := ch.L()
+	llt, _ := Mul(l, l.T())
+	for i := range a.Data {
+		eps.EqFloat64(a.Data[i], llt.Data[i], "Cholesky L", t)
+	}
+
+	inv, err := ch.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, _ := Mul(a, inv)
+	checkIdentity(prod, "Cholesky Inverse", t)
+
+	x, err := ch.Solve([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := MulVec(a, x)
+	for i, b1 := range []float64{1, 2, 3} {
+		eps.EqFloat64(b1, b[i], "Cholesky Solve", t)
+	}
+
+	ld, _, _ := LogDet(a)
+	eps.EqFloat64(ld, ch.LogDet(), "Cholesky LogDet", t)
+
+	// Not positive definite
+	s, _ := View(2, 2, []float64{1, 2, 2, 1})
+	if _, err = NewCholesky(s); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestQR(t *testing.T) {
+	// Fit a straight line through exact points
+	a, _ := View(4, 2, []float64{1, 0, 1, 1, 1, 2, 1, 3})
+	b := []float64{1, 3, 5, 7}
+	qr, err := NewQR(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, resid, err := qr.LeastSquares(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(1, x[0], "QR intercept", t)
+	eps.EqFloat64(2, x[1], "QR slope", t)
+	for i := range resid {
+		eps.EqFloat64(0, resid[i], "QR residual", t)
+	}
+}
+
+func TestSVD(t *testing.T) {
+	a := testMatrix()
+	sv, err := NewSVD(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// For a symmetric positive-definite matrix, the singular values are the eigenvalues
+	eval, _, err := SymmEigen(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range sv.S {
+		eps.EqFloat64(eval[len(eval)-1-i], sv.S[i], "SVD singular values", t)
+	}
+	x, err := sv.Solve([]float64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := MulVec(a, x)
+	for i, b1 := range []float64{1, 2, 3} {
+		eps.EqFloat64(b1, b[i], "SVD Solve", t)
+	}
+
+	if _, err = NewSVD(NewMatrix(2, 3)); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestSymmEigen(t *testing.T) {
+	a := testMatrix()
+	eval, evec, err := SymmEigen(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(eval); i++ {
+		if eval[i] < eval[i-1] {
+			t.Errorf("Eigenvalues not sorted : %v", eval)
+		}
+	}
+	for j := range eval {
+		v := evec.Col(j)
+		av, _ := MulVec(a, v)
+		for i := range v {
+			eps.EqFloat64(eval[j]*v[i], av[i], "Eigenvector", t)
+		}
+	}
+}
+
+func TestHartlap(t *testing.T) {
+	a := testMatrix()
+	inv, err := HartlapInverse(a, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv1, _ := Inverse(a)
+	fac := float64(100-3-2) / float64(100-1)
+	for i := range inv.Data {
+		eps.EqFloat64(fac*inv1.Data[i], inv.Data[i], "Hartlap", t)
+	}
+
+	if _, err = HartlapInverse(a, 5); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
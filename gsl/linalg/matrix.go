@@ -0,0 +1,125 @@
+// Package linalg wraps the GSL linear algebra routines, BLAS and eigensolvers.
+//
+// Matrices are stored row-major in Go slices, and are passed to GSL as views
+// without copying. Decompositions work on a copy of their input, leaving it untouched.
+package linalg
+
+import "C"
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Matrix is a dense, row-major matrix. Element (i,j) is Data[i*Cols+j].
+type Matrix struct {
+	Rows, Cols int
+	Data       []float64
+}
+
+// NewMatrix returns a zero matrix of size rows x cols
+func NewMatrix(rows, cols int) *Matrix {
+	return &Matrix{rows, cols, make([]float64, rows*cols)}
+}
+
+// View wraps data as a rows x cols matrix, without copying.
+func View(rows, cols int, data []float64) (*Matrix, error) {
+	if rows < 0 || cols < 0 {
+		return nil, fmt.Errorf("Negative dimensions in View: %d x %d", rows, cols)
+	}
+	if len(data) != rows*cols {
+		return nil, fmt.Errorf("Incompatible dimensions in View: data(%d) != %d x %d", len(data), rows, cols)
+	}
+	return &Matrix{rows, cols, data}, nil
+}
+
+// Identity returns the n x n identity matrix
+func Identity(n int) *Matrix {
+	m := NewMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.Data[i*n+i] = 1
+	}
+	return m
+}
+
+// At returns element (i,j)
+func (m *Matrix) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// Set sets element (i,j) to x
+func (m *Matrix) Set(i, j int, x float64) {
+	m.Data[i*m.Cols+j] = x
+}
+
+// Row returns row i. This shares storage with m.
+func (m *Matrix) Row(i int) []float64 {
+	return m.Data[i*m.Cols : (i+1)*m.Cols]
+}
+
+// Col returns a copy of column j
+func (m *Matrix) Col(j int) []float64 {
+	arr := make([]float64, m.Rows)
+	for i := range arr {
+		arr[i] = m.Data[i*m.Cols+j]
+	}
+	return arr
+}
+
+// Copy returns a copy of m
+func (m *Matrix) Copy() *Matrix {
+	m1 := NewMatrix(m.Rows, m.Cols)
+	copy(m1.Data, m.Data)
+	return m1
+}
+
+// T returns the transpose of m, as a new matrix
+func (m *Matrix) T() *Matrix {
+	m1 := NewMatrix(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			m1.Data[j*m.Rows+i] = m.Data[i*m.Cols+j]
+		}
+	}
+	return m1
+}
+
+// Scale multiplies every element of m by x
+func (m *Matrix) Scale(x float64) {
+	for i := range m.Data {
+		m.Data[i] *= x
+	}
+}
+
+// ptr returns a pointer to the data, for GSL. Call check first.
+func (m *Matrix) ptr() *C.double {
+	return (*C.double)(&m.Data[0])
+}
+
+// check returns an error if m is empty, or if Data does not hold Rows x Cols elements
+func (m *Matrix) check() error {
+	if m.Rows < 0 || m.Cols < 0 {
+		return fmt.Errorf("Negative dimensions: %d x %d", m.Rows, m.Cols)
+	}
+	if len(m.Data) != m.Rows*m.Cols {
+		return fmt.Errorf("Incompatible dimensions: data(%d) != %d x %d", len(m.Data), m.Rows, m.Cols)
+	}
+	if len(m.Data) == 0 {
+		return errors.New("Empty matrix")
+	}
+	return nil
+}
+
+func (m *Matrix) checkSquare() error {
+	if err := m.check(); err != nil {
+		return err
+	}
+	if m.Rows != m.Cols {
+		return fmt.Errorf("Matrix not square : %d x %d", m.Rows, m.Cols)
+	}
+	return nil
+}
+
+func vptr(x []float64) *C.double {
+	return (*C.double)(&x[0])
+}
@@ -5,10 +5,39 @@ package sf
 
 
 #include "gsl/gsl_sf_bessel.h"
+
+// sphbessel_slice fills out[i] = jl(k x[i]), stopping at the first error
+static int sphbessel_slice(int l, double k, const double *x, double *out, size_t n) {
+	gsl_sf_result r;
+	int ret;
+	size_t i;
+	for (i = 0; i < n; i++) {
+		switch (l) {
+		case 0 :
+			ret = gsl_sf_bessel_j0_e(k * x[i], &r);
+			break;
+		case 1 :
+			ret = gsl_sf_bessel_j1_e(k * x[i], &r);
+			break;
+		case 2 :
+			ret = gsl_sf_bessel_j2_e(k * x[i], &r);
+			break;
+		default :
+			ret = gsl_sf_bessel_jl_e(l, k * x[i], &r);
+		}
+		if (ret != 0) {
+			return ret;
+		}
+		out[i] = r.val;
+	}
+	return 0;
+}
 */
 import "C"
 
 import (
+	"fmt"
+
 	"github.com/npadmana/npgo/gsl"
 )
 
@@ -29,11 +58,26 @@ func BesselJ(n int, x float64) float64 {
 // BesselJArr returns an array of Jn(x) where n runs from nmin to nmax inclusive
 func BesselJArr(nmin, nmax int, x float64) []float64 {
 	arr := make([]float64, nmax-nmin+1)
+	if err := BesselJFill(nmin, nmax, x, arr); err != nil {
+		panic(err)
+	}
+	return arr
+}
+
+// BesselJFill fills arr with Jn(x) where n runs from nmin to nmax inclusive.
+// arr must have length nmax-nmin+1.
+func BesselJFill(nmin, nmax int, x float64, arr []float64) error {
+	if nmax < nmin {
+		return fmt.Errorf("Empty range in BesselJFill: nmin(%d) > nmax(%d)", nmin, nmax)
+	}
+	if len(arr) != nmax-nmin+1 {
+		return fmt.Errorf("Incompatible dimensions in BesselJFill: arr(%d) != %d", len(arr), nmax-nmin+1)
+	}
 	ret := C.gsl_sf_bessel_Jn_array(C.int(nmin), C.int(nmax), C.double(x), (*C.double)(&arr[0]))
 	if ret != 0 {
-		panic(gsl.Errno(ret))
+		return gsl.Errno(ret)
 	}
-	return arr
+	return nil
 }
 
 // SphBessel returns the spherical bessel function jl(x)
@@ -58,9 +102,148 @@ func SphBessel(l int, x float64) float64 {
 // on Steed's algorithm.
 func SphBesselArr(lmax int, x float64) []float64 {
 	arr := make([]float64, lmax+1)
+	if err := SphBesselFill(lmax, x, arr); err != nil {
+		panic(err)
+	}
+	return arr
+}
+
+// SphBesselFill fills arr with jl(x) where l runs from 0 to lmax inclusive.
+// arr must have length lmax+1.
+func SphBesselFill(lmax int, x float64, arr []float64) error {
+	if lmax < 0 {
+		return fmt.Errorf("lmax must be non-negative, got %d", lmax)
+	}
+	if len(arr) != lmax+1 {
+		return fmt.Errorf("Incompatible dimensions in SphBesselFill: arr(%d) != %d", len(arr), lmax+1)
+	}
 	ret := C.gsl_sf_bessel_jl_array(C.int(lmax), C.double(x), (*C.double)(&arr[0]))
 	if ret != 0 {
-		panic(gsl.Errno(ret))
+		return gsl.Errno(ret)
 	}
-	return arr
+	return nil
+}
+
+// SphBesselSlice fills out[i] = jl(x[i]), for a fixed l. out must be the same length as x.
+//
+// The loop is done in C, avoiding the cgo overhead of calling SphBessel at every point.
+func SphBesselSlice(l int, x, out []float64) error {
+	return SphBesselScaled(l, 1, x, out)
+}
+
+// SphBesselScaled fills out[i] = jl(k x[i]), for a fixed l. out must be the same length as x.
+// This is the inner loop of a Hankel transform, with x the radii.
+func SphBesselScaled(l int, k float64, x, out []float64) error {
+	if len(out) != len(x) {
+		return fmt.Errorf("Incompatible dimensions in SphBesselScaled: x(%d) != out(%d)", len(x), len(out))
+	}
+	if len(x) == 0 {
+		return nil
+	}
+	ret := C.sphbessel_slice(C.int(l), C.double(k), (*C.double)(&x[0]), (*C.double)(&out[0]), C.size_t(len(x)))
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	return nil
+}
+
+// BesselJE returns Jn(x), with an error estimate
+func BesselJE(n int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_Jn_e(C.int(n), C.double(x), &r)
+	return result(r, ret)
+}
+
+// BesselY returns Yn(x), the irregular cylindrical Bessel function. x must be positive.
+func BesselY(n int, x float64) float64 {
+	var y C.double
+	switch n {
+	case 0:
+		y = C.gsl_sf_bessel_Y0(C.double(x))
+	case 1:
+		y = C.gsl_sf_bessel_Y1(C.double(x))
+	default:
+		y = C.gsl_sf_bessel_Yn(C.int(n), C.double(x))
+	}
+	return float64(y)
+}
+
+// BesselYE returns Yn(x), with an error estimate
+func BesselYE(n int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_Yn_e(C.int(n), C.double(x), &r)
+	return result(r, ret)
+}
+
+// BesselI returns In(x), the regular modified cylindrical Bessel function
+func BesselI(n int, x float64) float64 {
+	var y C.double
+	switch n {
+	case 0:
+		y = C.gsl_sf_bessel_I0(C.double(x))
+	case 1:
+		y = C.gsl_sf_bessel_I1(C.double(x))
+	default:
+		y = C.gsl_sf_bessel_In(C.int(n), C.double(x))
+	}
+	return float64(y)
+}
+
+// BesselIE returns In(x), with an error estimate
+func BesselIE(n int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_In_e(C.int(n), C.double(x), &r)
+	return result(r, ret)
+}
+
+// BesselK returns Kn(x), the irregular modified cylindrical Bessel function.
+// x must be positive.
+func BesselK(n int, x float64) float64 {
+	var y C.double
+	switch n {
+	case 0:
+		y = C.gsl_sf_bessel_K0(C.double(x))
+	case 1:
+		y = C.gsl_sf_bessel_K1(C.double(x))
+	default:
+		y = C.gsl_sf_bessel_Kn(C.int(n), C.double(x))
+	}
+	return float64(y)
+}
+
+// BesselKE returns Kn(x), with an error estimate
+func BesselKE(n int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_Kn_e(C.int(n), C.double(x), &r)
+	return result(r, ret)
+}
+
+// SphBesselE returns jl(x), with an error estimate
+func SphBesselE(l int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_jl_e(C.int(l), C.double(x), &r)
+	return result(r, ret)
+}
+
+// SphBesselY returns the irregular spherical bessel function yl(x). x must be positive.
+func SphBesselY(l int, x float64) float64 {
+	var y C.double
+	switch l {
+	case 0:
+		y = C.gsl_sf_bessel_y0(C.double(x))
+	case 1:
+		y = C.gsl_sf_bessel_y1(C.double(x))
+	case 2:
+		y = C.gsl_sf_bessel_y2(C.double(x))
+	default:
+		y = C.gsl_sf_bessel_yl(C.int(l), C.double(x))
+	}
+	return float64(y)
+}
+
+// SphBesselYE returns yl(x), with an error estimate
+func SphBesselYE(l int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_bessel_yl_e(C.int(l), C.double(x), &r)
+	return result(r, ret)
 }
@@ -0,0 +1,37 @@
+package sf
+
+/*
+#cgo pkg-config: gsl
+
+
+#include "gsl/gsl_sf_erf.h"
+*/
+import "C"
+
+import (
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Erf returns the error function
+func Erf(x float64) float64 {
+	return float64(C.gsl_sf_erf(C.double(x)))
+}
+
+// ErfE returns the error function, with an error estimate
+func ErfE(x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_erf_e(C.double(x), &r)
+	return result(r, ret)
+}
+
+// Erfc returns the complementary error function, 1 - erf(x)
+func Erfc(x float64) float64 {
+	return float64(C.gsl_sf_erfc(C.double(x)))
+}
+
+// ErfcE returns the complementary error function, with an error estimate
+func ErfcE(x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_erfc_e(C.double(x), &r)
+	return result(r, ret)
+}
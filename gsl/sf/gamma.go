@@ -0,0 +1,86 @@
+package sf
+
+/*
+#cgo pkg-config: gsl
+
+
+#include "gsl/gsl_sf_gamma.h"
+*/
+import "C"
+
+import (
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Gamma returns the gamma function
+func Gamma(x float64) float64 {
+	return float64(C.gsl_sf_gamma(C.double(x)))
+}
+
+// GammaE returns the gamma function, with an error estimate
+func GammaE(x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_gamma_e(C.double(x), &r)
+	return result(r, ret)
+}
+
+// LnGamma returns log(|Gamma(x)|)
+func LnGamma(x float64) float64 {
+	return float64(C.gsl_sf_lngamma(C.double(x)))
+}
+
+// LnGammaE returns log(|Gamma(x)|), with an error estimate
+func LnGammaE(x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_lngamma_e(C.double(x), &r)
+	return result(r, ret)
+}
+
+// Beta returns the beta function B(a,b) = Gamma(a)Gamma(b)/Gamma(a+b)
+func Beta(a, b float64) float64 {
+	return float64(C.gsl_sf_beta(C.double(a), C.double(b)))
+}
+
+// BetaE returns B(a,b), with an error estimate
+func BetaE(a, b float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_beta_e(C.double(a), C.double(b), &r)
+	return result(r, ret)
+}
+
+// GammaInc returns the unnormalized upper incomplete gamma function Gamma(a,x)
+func GammaInc(a, x float64) float64 {
+	return float64(C.gsl_sf_gamma_inc(C.double(a), C.double(x)))
+}
+
+// GammaIncE returns Gamma(a,x), with an error estimate
+func GammaIncE(a, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_gamma_inc_e(C.double(a), C.double(x), &r)
+	return result(r, ret)
+}
+
+// GammaIncP returns the normalized lower incomplete gamma function P(a,x), for a > 0
+func GammaIncP(a, x float64) float64 {
+	return float64(C.gsl_sf_gamma_inc_P(C.double(a), C.double(x)))
+}
+
+// GammaIncPE returns P(a,x), with an error estimate
+func GammaIncPE(a, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_gamma_inc_P_e(C.double(a), C.double(x), &r)
+	return result(r, ret)
+}
+
+// GammaIncQ returns the normalized upper incomplete gamma function Q(a,x) = 1 - P(a,x),
+// for a > 0
+func GammaIncQ(a, x float64) float64 {
+	return float64(C.gsl_sf_gamma_inc_Q(C.double(a), C.double(x)))
+}
+
+// GammaIncQE returns Q(a,x), with an error estimate
+func GammaIncQE(a, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_gamma_inc_Q_e(C.double(a), C.double(x), &r)
+	return result(r, ret)
+}
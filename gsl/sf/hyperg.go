@@ -0,0 +1,44 @@
+package sf
+
+/*
+#cgo pkg-config: gsl
+
+
+#include "gsl/gsl_sf_hyperg.h"
+*/
+import "C"
+
+import (
+	"math"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Hyperg2F1 returns the Gauss hypergeometric function 2F1(a,b;c;x), for x < 1.
+//
+// GSL only handles -1 <= x < 1; for x < -1 we use the Pfaff transformation
+//
+//	2F1(a,b;c;x) = (1-x)^(-a) 2F1(a,c-b;c;x/(x-1)).
+//
+// This case shows up eg. in the analytic LCDM growth factor.
+func Hyperg2F1(a, b, c, x float64) float64 {
+	if x < -1 {
+		return math.Pow(1-x, -a) * float64(C.gsl_sf_hyperg_2F1(C.double(a), C.double(c-b), C.double(c), C.double(x/(x-1))))
+	}
+	return float64(C.gsl_sf_hyperg_2F1(C.double(a), C.double(b), C.double(c), C.double(x)))
+}
+
+// Hyperg2F1E returns 2F1(a,b;c;x), with an error estimate
+func Hyperg2F1E(a, b, c, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	if x < -1 {
+		ret := C.gsl_sf_hyperg_2F1_e(C.double(a), C.double(c-b), C.double(c), C.double(x/(x-1)), &r)
+		res, err := result(r, ret)
+		fac := math.Pow(1-x, -a)
+		res.Res *= fac
+		res.Err *= math.Abs(fac)
+		return res, err
+	}
+	ret := C.gsl_sf_hyperg_2F1_e(C.double(a), C.double(b), C.double(c), C.double(x), &r)
+	return result(r, ret)
+}
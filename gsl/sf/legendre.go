@@ -0,0 +1,102 @@
+package sf
+
+/*
+#cgo pkg-config: gsl
+
+
+#include "gsl/gsl_sf_legendre.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// LegendreP returns the Legendre polynomial Pl(x)
+func LegendreP(l int, x float64) float64 {
+	return float64(C.gsl_sf_legendre_Pl(C.int(l), C.double(x)))
+}
+
+// LegendrePE returns Pl(x), with an error estimate
+func LegendrePE(l int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_legendre_Pl_e(C.int(l), C.double(x), &r)
+	return result(r, ret)
+}
+
+// LegendrePArr returns an array of Pl(x) where l runs from 0 to lmax inclusive
+func LegendrePArr(lmax int, x float64) ([]float64, error) {
+	if lmax < 0 {
+		return nil, fmt.Errorf("lmax must be non-negative, got %d", lmax)
+	}
+	arr := make([]float64, lmax+1)
+	if err := LegendrePFill(lmax, x, arr); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// LegendrePFill fills arr with Pl(x) where l runs from 0 to lmax inclusive.
+// arr must have length lmax+1.
+func LegendrePFill(lmax int, x float64, arr []float64) error {
+	if lmax < 0 {
+		return fmt.Errorf("lmax must be non-negative, got %d", lmax)
+	}
+	if len(arr) != lmax+1 {
+		return fmt.Errorf("Incompatible dimensions in LegendrePFill: arr(%d) != %d", len(arr), lmax+1)
+	}
+	ret := C.gsl_sf_legendre_Pl_array(C.int(lmax), C.double(x), (*C.double)(&arr[0]))
+	if ret != 0 {
+		return gsl.Errno(ret)
+	}
+	return nil
+}
+
+// LegendrePlm returns the associated Legendre polynomial Plm(x), for m >= 0.
+// This overflows for large l; use LegendreSphPlm instead.
+func LegendrePlm(l, m int, x float64) float64 {
+	return float64(C.gsl_sf_legendre_Plm(C.int(l), C.int(m), C.double(x)))
+}
+
+// LegendrePlmE returns Plm(x), with an error estimate
+func LegendrePlmE(l, m int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_legendre_Plm_e(C.int(l), C.int(m), C.double(x), &r)
+	return result(r, ret)
+}
+
+// LegendreSphPlm returns the normalized associated Legendre polynomial
+// sqrt((2l+1)/(4pi)) sqrt((l-m)!/(l+m)!) Plm(x), for m >= 0. This is suitable for
+// spherical harmonics, and does not overflow for large l.
+func LegendreSphPlm(l, m int, x float64) float64 {
+	return float64(C.gsl_sf_legendre_sphPlm(C.int(l), C.int(m), C.double(x)))
+}
+
+// LegendreSphPlmE returns the normalized Plm(x), with an error estimate
+func LegendreSphPlmE(l, m int, x float64) (gsl.Result, error) {
+	var r C.gsl_sf_result
+	ret := C.gsl_sf_legendre_sphPlm_e(C.int(l), C.int(m), C.double(x), &r)
+	return result(r, ret)
+}
+
+// SphHarmonic returns the spherical harmonic Ylm(theta, phi), with the Condon-Shortley
+// phase. Negative m is allowed, using Yl,-m = (-1)^m conj(Ylm).
+func SphHarmonic(l, m int, theta, phi float64) complex128 {
+	am := m
+	if m < 0 {
+		am = -m
+	}
+	plm := LegendreSphPlm(l, am, math.Cos(theta))
+	y := complex(plm, 0) * cmplx.Exp(complex(0, float64(am)*phi))
+	if m < 0 {
+		y = cmplx.Conj(y)
+		if am%2 == 1 {
+			y = -y
+		}
+	}
+	return y
+}
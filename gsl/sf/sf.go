@@ -0,0 +1,26 @@
+// Package sf wraps the GSL special functions.
+//
+// Most functions come in two forms : a plain version that returns a float64, and
+// a version with an E suffix that returns a gsl.Result with an error estimate, and
+// an error if GSL reported one.
+package sf
+
+/*
+#cgo pkg-config: gsl
+
+#include "gsl/gsl_sf_result.h"
+*/
+import "C"
+
+import (
+	"github.com/npadmana/npgo/gsl"
+)
+
+// result converts a gsl_sf_result and return code into a gsl.Result and error
+func result(r C.gsl_sf_result, ret C.int) (gsl.Result, error) {
+	res := gsl.Result{Res: float64(r.val), Err: float64(r.err)}
+	if ret != 0 {
+		return res, gsl.Errno(ret)
+	}
+	return res, nil
+}
@@ -0,0 +1,57 @@
+package sf_test
+
+import (
+	"testing"
+
+	. "github.com/npadmana/npgo/gsl/sf"
+)
+
+const nbench = 1000
+
+func benchRadii() ([]float64, []float64) {
+	r := make([]float64, nbench)
+	for i := range r {
+		r[i] = 0.5 + float64(i)
+	}
+	return r, make([]float64, nbench)
+}
+
+func BenchmarkSphBessel(b *testing.B) {
+	r, out := benchRadii()
+	k := 0.1
+	for i := 0; i < b.N; i++ {
+		for j := range r {
+			out[j] = SphBessel(2, k*r[j])
+		}
+	}
+}
+
+func BenchmarkSphBesselScaled(b *testing.B) {
+	r, out := benchRadii()
+	k := 0.1
+	for i := 0; i < b.N; i++ {
+		if err := SphBesselScaled(2, k, r, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSphBesselHighL(b *testing.B) {
+	r, out := benchRadii()
+	k := 0.1
+	for i := 0; i < b.N; i++ {
+		for j := range r {
+			out[j] = SphBessel(10, k*r[j])
+		}
+	}
+}
+
+func BenchmarkSphBesselScaledHighL(b *testing.B) {
+	r, out := benchRadii()
+	k := 0.1
+	for i := 0; i < b.N; i++ {
+		if err := SphBesselScaled(10, k, r, out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
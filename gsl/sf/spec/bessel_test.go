@@ -1,6 +1,8 @@
 package sf_test
 
 import (
+	"math"
+
 	. "github.com/npadmana/npgo/gsl/sf"
 
 	. "github.com/onsi/ginkgo"
@@ -109,3 +111,116 @@ var _ = Describe("SphBessel", func() {
 		})
 	})
 })
+
+var _ = Describe("Bessel variants", func() {
+	Context("closed forms", func() {
+		It("should agree for Y_n", func() {
+			// Y_{n+1} = (2n/x) Y_n - Y_{n-1}
+			x := 3.0
+			Expect(BesselY(2, x)).To(BeNumerically("~", 2/x*BesselY(1, x)-BesselY(0, x), 1.e-13))
+		})
+		It("should agree for I_n", func() {
+			Expect(BesselI(0, 0)).To(BeNumerically("~", 1, 1.e-13))
+			x := 1.5
+			Expect(BesselI(2, x)).To(BeNumerically("~", BesselI(0, x)-2/x*BesselI(1, x), 1.e-13))
+		})
+		It("should agree for K_n", func() {
+			x := 1.5
+			Expect(BesselK(2, x)).To(BeNumerically("~", BesselK(0, x)+2/x*BesselK(1, x), 1.e-13))
+		})
+		It("should agree for y_l", func() {
+			for _, x := range []float64{0.5, 2, 10} {
+				Expect(SphBesselY(0, x)).To(BeNumerically("~", -math.Cos(x)/x, 1.e-13))
+				Expect(SphBesselY(1, x)).To(BeNumerically("~", -math.Cos(x)/(x*x)-math.Sin(x)/x, 1.e-13))
+			}
+		})
+	})
+
+	Context("error estimates", func() {
+		It("should agree with the plain versions", func() {
+			x := 2.5
+			res, err := BesselJE(3, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", BesselJ(3, x), 1.e-13))
+			Expect(res.Err).To(BeNumerically(">=", 0))
+
+			res, err = SphBesselE(4, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", SphBessel(4, x), 1.e-13))
+
+			res, err = SphBesselYE(4, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", SphBesselY(4, x), 1.e-13))
+
+			res, err = BesselIE(2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", BesselI(2, x), 1.e-13))
+
+			res, err = BesselYE(2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", BesselY(2, x), 1.e-13))
+		})
+		It("should report domain errors", func() {
+			_, err := BesselKE(0, -1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Array evaluation", func() {
+	Context("BesselJFill", func() {
+		x := 12.75
+		It("should agree with BesselJArr", func() {
+			arr := make([]float64, 11)
+			Expect(BesselJFill(0, 10, x, arr)).NotTo(HaveOccurred())
+			js := BesselJArr(0, 10, x)
+			for i := range js {
+				Expect(arr[i]).To(BeNumerically("~", js[i], 1.e-13))
+			}
+		})
+		It("should report bad dimensions", func() {
+			Expect(BesselJFill(0, 10, x, make([]float64, 5))).To(HaveOccurred())
+			Expect(BesselJFill(10, 0, x, make([]float64, 5))).To(HaveOccurred())
+		})
+	})
+
+	Context("SphBesselFill", func() {
+		x := 12.75
+		It("should agree with SphBesselArr", func() {
+			arr := make([]float64, 11)
+			Expect(SphBesselFill(10, x, arr)).NotTo(HaveOccurred())
+			js := SphBesselArr(10, x)
+			for i := range js {
+				Expect(arr[i]).To(BeNumerically("~", js[i], 1.e-13))
+			}
+		})
+		It("should report bad dimensions", func() {
+			Expect(SphBesselFill(10, x, make([]float64, 5))).To(HaveOccurred())
+			Expect(SphBesselFill(-1, x, make([]float64, 0))).To(HaveOccurred())
+		})
+	})
+
+	Context("SphBesselSlice", func() {
+		xx := []float64{0, 0.1, 1.0, 2, 10.0, 100}
+		out := make([]float64, len(xx))
+		It("should agree with SphBessel", func() {
+			for _, l := range []int{0, 1, 2, 5, 7} {
+				Expect(SphBesselSlice(l, xx, out)).NotTo(HaveOccurred())
+				for i, x := range xx {
+					Expect(out[i]).To(BeNumerically("~", SphBessel(l, x), 1.e-13))
+				}
+			}
+		})
+		It("should scale its arguments", func() {
+			k := 0.37
+			Expect(SphBesselScaled(3, k, xx, out)).NotTo(HaveOccurred())
+			for i, x := range xx {
+				Expect(out[i]).To(BeNumerically("~", SphBessel(3, k*x), 1.e-13))
+			}
+		})
+		It("should report errors", func() {
+			Expect(SphBesselSlice(2, xx, out[1:])).To(HaveOccurred())
+			Expect(SphBesselSlice(-1, xx, out)).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package sf_test
+
+import (
+	"math"
+
+	. "github.com/npadmana/npgo/gsl/sf"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Gamma", func() {
+	xx := []float64{0.3, 1, 2.5, 4.5, 10.3}
+
+	It("should agree with math.Gamma", func() {
+		for _, x := range xx {
+			Expect(Gamma(x)).To(BeNumerically("~", math.Gamma(x), 1.e-12*math.Gamma(x)))
+			res, err := GammaE(x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", math.Gamma(x), 1.e-12*math.Gamma(x)))
+		}
+	})
+
+	It("should agree with math.Lgamma", func() {
+		for _, x := range xx {
+			lg, _ := math.Lgamma(x)
+			Expect(LnGamma(x)).To(BeNumerically("~", lg, 1.e-12))
+			res, err := LnGammaE(x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", lg, 1.e-12))
+		}
+	})
+
+	It("should report an error at the poles", func() {
+		_, err := GammaE(-1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should give the beta function", func() {
+		Expect(Beta(2, 3)).To(BeNumerically("~", 1.0/12, 1.e-13))
+		res, err := BetaE(0.5, 0.5)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.Res).To(BeNumerically("~", math.Pi, 1.e-13))
+	})
+
+	Context("incomplete gamma functions", func() {
+		// For a=2, Gamma(2,x) = (1+x) exp(-x)
+		x := 1.5
+		q := (1 + x) * math.Exp(-x)
+		It("should agree with the closed form", func() {
+			Expect(GammaInc(2, x)).To(BeNumerically("~", q, 1.e-13))
+			Expect(GammaIncQ(2, x)).To(BeNumerically("~", q, 1.e-13))
+			Expect(GammaIncP(2, x)).To(BeNumerically("~", 1-q, 1.e-13))
+		})
+		It("should agree with the E variants", func() {
+			res, err := GammaIncE(2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", q, 1.e-13))
+			res, err = GammaIncQE(2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", q, 1.e-13))
+			res, err = GammaIncPE(2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", 1-q, 1.e-13))
+		})
+	})
+})
+
+var _ = Describe("Erf", func() {
+	xx := []float64{-2, -0.3, 0, 0.7, 3}
+	It("should agree with math.Erf and math.Erfc", func() {
+		for _, x := range xx {
+			Expect(Erf(x)).To(BeNumerically("~", math.Erf(x), 1.e-14))
+			Expect(Erfc(x)).To(BeNumerically("~", math.Erfc(x), 1.e-14))
+			res, err := ErfE(x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", math.Erf(x), 1.e-14))
+			res, err = ErfcE(x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", math.Erfc(x), 1.e-14))
+		}
+	})
+})
+
+var _ = Describe("Hyperg2F1", func() {
+	// 2F1(1,1;2;x) = -log(1-x)/x
+	ff := func(x float64) float64 { return -math.Log(1-x) / x }
+	It("should agree with the closed form for |x| < 1", func() {
+		for _, x := range []float64{-0.9, -0.5, 0.3, 0.8} {
+			Expect(Hyperg2F1(1, 1, 2, x)).To(BeNumerically("~", ff(x), 1.e-13))
+		}
+	})
+	It("should agree with the closed form for x < -1", func() {
+		for _, x := range []float64{-1.5, -3, -20} {
+			Expect(Hyperg2F1(1, 1, 2, x)).To(BeNumerically("~", ff(x), 1.e-13))
+			res, err := Hyperg2F1E(1, 1, 2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", ff(x), 1.e-13))
+		}
+	})
+})
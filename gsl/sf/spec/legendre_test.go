@@ -0,0 +1,86 @@
+package sf_test
+
+import (
+	"math"
+	"math/cmplx"
+
+	. "github.com/npadmana/npgo/gsl/sf"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Legendre", func() {
+	mu := []float64{-0.9, -0.3, 0, 0.3, 0.75, 1}
+
+	Context("low order polynomials", func() {
+		It("should agree with the closed forms", func() {
+			for _, x := range mu {
+				Expect(LegendreP(0, x)).To(BeNumerically("~", 1, 1.e-13))
+				Expect(LegendreP(1, x)).To(BeNumerically("~", x, 1.e-13))
+				Expect(LegendreP(2, x)).To(BeNumerically("~", (3*x*x-1)/2, 1.e-13))
+				Expect(LegendreP(4, x)).To(BeNumerically("~", (35*x*x*x*x-30*x*x+3)/8, 1.e-13))
+			}
+		})
+		It("should agree with the E variant", func() {
+			res, err := LegendrePE(6, 0.4)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", LegendreP(6, 0.4), 1.e-13))
+		})
+	})
+
+	Context("LegendrePArr", func() {
+		arr, err := LegendrePArr(8, 0.3)
+		It("should have length 9", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(arr).To(HaveLen(9))
+		})
+		It("should agree with LegendreP", func() {
+			for l := range arr {
+				Expect(arr[l]).To(BeNumerically("~", LegendreP(l, 0.3), 1.e-13))
+			}
+		})
+		It("should report errors", func() {
+			_, err := LegendrePArr(8, 1.5)
+			Expect(err).To(HaveOccurred())
+			_, err = LegendrePArr(-1, 0.3)
+			Expect(err).To(HaveOccurred())
+			Expect(LegendrePFill(8, 0.3, make([]float64, 5))).To(HaveOccurred())
+		})
+	})
+
+	Context("associated Legendre polynomials", func() {
+		It("should agree with the closed forms", func() {
+			for _, x := range mu {
+				Expect(LegendrePlm(1, 1, x)).To(BeNumerically("~", -math.Sqrt(1-x*x), 1.e-13))
+				Expect(LegendrePlm(2, 2, x)).To(BeNumerically("~", 3*(1-x*x), 1.e-13))
+			}
+		})
+		It("should be normalized for spherical harmonics", func() {
+			x := 0.3
+			norm := math.Sqrt(5 / (4 * math.Pi) / 24)
+			Expect(LegendreSphPlm(2, 2, x)).To(BeNumerically("~", norm*LegendrePlm(2, 2, x), 1.e-13))
+			res, err := LegendreSphPlmE(2, 2, x)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Res).To(BeNumerically("~", norm*LegendrePlm(2, 2, x), 1.e-13))
+		})
+		It("should report domain errors", func() {
+			_, err := LegendrePlmE(2, 3, 0.5)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("spherical harmonics", func() {
+		theta, phi := 0.4, 0.7
+		It("should agree with Y_11", func() {
+			y11 := complex(-math.Sqrt(3/(8*math.Pi))*math.Sin(theta), 0) * cmplx.Exp(complex(0, phi))
+			Expect(cmplx.Abs(SphHarmonic(1, 1, theta, phi) - y11)).To(BeNumerically("<", 1.e-13))
+			Expect(cmplx.Abs(SphHarmonic(1, -1, theta, phi) + cmplx.Conj(y11))).To(BeNumerically("<", 1.e-13))
+		})
+		It("should reduce to Legendre polynomials for m=0", func() {
+			y := SphHarmonic(3, 0, theta, phi)
+			Expect(real(y)).To(BeNumerically("~", math.Sqrt(7/(4*math.Pi))*LegendreP(3, math.Cos(theta)), 1.e-13))
+			Expect(imag(y)).To(BeNumerically("~", 0, 1.e-13))
+		})
+	})
+})
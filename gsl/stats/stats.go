@@ -0,0 +1,188 @@
+// Package stats wraps the GSL statistics routines.
+//
+// All functions take a slice and a stride, so that they can operate directly on a column
+// of a row-major table (eg. one read in with lineio.ParseToFloat64Arr). Column c of a table
+// with ncol columns is passed as (data[c:], ncol). The number of elements is
+// (len(data)+stride-1)/stride. Functions of a single array return NaN for empty data.
+package stats
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_statistics_double.h>
+#include <gsl/gsl_sort_double.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// size returns the number of elements in data, with stride
+func size(data []float64, stride int) int {
+	if stride < 1 {
+		panic(fmt.Errorf("stride must be positive, got %d", stride))
+	}
+	return (len(data) + stride - 1) / stride
+}
+
+func ptr(data []float64) *C.double {
+	return (*C.double)(&data[0])
+}
+
+// Mean returns the mean of data
+func Mean(data []float64, stride int) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_mean(ptr(data), C.size_t(stride), C.size_t(n)))
+}
+
+// Variance returns the sample variance of data, normalized by n-1
+func Variance(data []float64, stride int) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_variance(ptr(data), C.size_t(stride), C.size_t(n)))
+}
+
+// Sd returns the sample standard deviation of data
+func Sd(data []float64, stride int) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_sd(ptr(data), C.size_t(stride), C.size_t(n)))
+}
+
+// Skew returns the skewness of data
+func Skew(data []float64, stride int) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_skew(ptr(data), C.size_t(stride), C.size_t(n)))
+}
+
+// Kurtosis returns the excess kurtosis of data
+func Kurtosis(data []float64, stride int) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_kurtosis(ptr(data), C.size_t(stride), C.size_t(n)))
+}
+
+// sorted returns a sorted, unit stride copy of data
+func sorted(data []float64, stride int) []float64 {
+	n := size(data, stride)
+	arr := make([]float64, n)
+	for i := range arr {
+		arr[i] = data[i*stride]
+	}
+	if n > 0 {
+		C.gsl_sort(ptr(arr), 1, C.size_t(n))
+	}
+	return arr
+}
+
+// Quantile returns the f quantile of data (0 <= f <= 1), interpolating linearly between
+// elements. data is not modified.
+func Quantile(data []float64, stride int, f float64) float64 {
+	arr := sorted(data, stride)
+	return QuantileSorted(arr, 1, f)
+}
+
+// QuantileSorted is Quantile for data that are already sorted in ascending order.
+func QuantileSorted(data []float64, stride int, f float64) float64 {
+	n := size(data, stride)
+	if n == 0 {
+		return math.NaN()
+	}
+	return float64(C.gsl_stats_quantile_from_sorted_data(ptr(data), C.size_t(stride), C.size_t(n), C.double(f)))
+}
+
+// Median returns the median of data. data is not modified.
+func Median(data []float64, stride int) float64 {
+	return Quantile(data, stride, 0.5)
+}
+
+// pair returns the common length of two strided arrays
+func pair(data1 []float64, stride1 int, data2 []float64, stride2 int) (int, error) {
+	n1 := size(data1, stride1)
+	n2 := size(data2, stride2)
+	if n1 != n2 {
+		return 0, fmt.Errorf("Incompatible dimensions : %d != %d", n1, n2)
+	}
+	if n1 == 0 {
+		return 0, errors.New("Empty data")
+	}
+	return n1, nil
+}
+
+// Covariance returns the sample covariance of data1 and data2
+func Covariance(data1 []float64, stride1 int, data2 []float64, stride2 int) (float64, error) {
+	n, err := pair(data1, stride1, data2, stride2)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_covariance(ptr(data1), C.size_t(stride1), ptr(data2), C.size_t(stride2), C.size_t(n))), nil
+}
+
+// Correlation returns the Pearson correlation coefficient of data1 and data2
+func Correlation(data1 []float64, stride1 int, data2 []float64, stride2 int) (float64, error) {
+	n, err := pair(data1, stride1, data2, stride2)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_correlation(ptr(data1), C.size_t(stride1), ptr(data2), C.size_t(stride2), C.size_t(n))), nil
+}
+
+// WMean returns the weighted mean of data, with weights w
+func WMean(w []float64, wstride int, data []float64, stride int) (float64, error) {
+	n, err := pair(w, wstride, data, stride)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_wmean(ptr(w), C.size_t(wstride), ptr(data), C.size_t(stride), C.size_t(n))), nil
+}
+
+// WVariance returns the weighted variance of data, with weights w
+func WVariance(w []float64, wstride int, data []float64, stride int) (float64, error) {
+	n, err := pair(w, wstride, data, stride)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_wvariance(ptr(w), C.size_t(wstride), ptr(data), C.size_t(stride), C.size_t(n))), nil
+}
+
+// WSd returns the weighted standard deviation of data, with weights w
+func WSd(w []float64, wstride int, data []float64, stride int) (float64, error) {
+	n, err := pair(w, wstride, data, stride)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_wsd(ptr(w), C.size_t(wstride), ptr(data), C.size_t(stride), C.size_t(n))), nil
+}
+
+// WSkew returns the weighted skewness of data, with weights w
+func WSkew(w []float64, wstride int, data []float64, stride int) (float64, error) {
+	n, err := pair(w, wstride, data, stride)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_wskew(ptr(w), C.size_t(wstride), ptr(data), C.size_t(stride), C.size_t(n))), nil
+}
+
+// WKurtosis returns the weighted excess kurtosis of data, with weights w
+func WKurtosis(w []float64, wstride int, data []float64, stride int) (float64, error) {
+	n, err := pair(w, wstride, data, stride)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return float64(C.gsl_stats_wkurtosis(ptr(w), C.size_t(wstride), ptr(data), C.size_t(stride), C.size_t(n))), nil
+}
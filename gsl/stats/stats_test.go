@@ -0,0 +1,91 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npadmana/npgo/nptest"
+)
+
+var eps = nptest.NewEps(1.e-12, 1.e-12)
+
+func TestMoments(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 10}
+	eps.EqFloat64(4, Mean(data, 1), "Mean", t)
+	eps.EqFloat64(12.5, Variance(data, 1), "Variance", t)
+	eps.EqFloat64(math.Sqrt(12.5), Sd(data, 1), "Sd", t)
+	if Skew(data, 1) <= 0 {
+		t.Errorf("Expected a positive skewness, got %f", Skew(data, 1))
+	}
+	if !math.IsNaN(Mean(nil, 1)) {
+		t.Error("Expected NaN for empty data")
+	}
+}
+
+func TestStride(t *testing.T) {
+	// A table with two columns
+	table := []float64{1, 10, 2, 20, 3, 30, 4, 40}
+	eps.EqFloat64(2.5, Mean(table, 2), "Mean column 0", t)
+	eps.EqFloat64(25, Mean(table[1:], 2), "Mean column 1", t)
+	eps.EqFloat64(2.5, Median(table, 2), "Median column 0", t)
+
+	r, err := Correlation(table, 2, table[1:], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(1, r, "Correlation", t)
+
+	c, err := Covariance(table, 2, table[1:], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(10*Variance(table, 2), c, "Covariance", t)
+
+	if _, err = Correlation(table, 2, table, 1); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	data := []float64{5, 1, 4, 2, 3}
+	eps.EqFloat64(3, Median(data, 1), "Median", t)
+	eps.EqFloat64(1, Quantile(data, 1, 0), "Quantile 0", t)
+	eps.EqFloat64(5, Quantile(data, 1, 1), "Quantile 1", t)
+	eps.EqFloat64(2, Quantile(data, 1, 0.25), "Quantile 0.25", t)
+	// data should not be modified
+	if data[0] != 5 || data[1] != 1 {
+		t.Errorf("Input was modified : %v", data)
+	}
+}
+
+func TestWeighted(t *testing.T) {
+	data := []float64{1, 2, 3, 4}
+	w := []float64{1, 1, 1, 1}
+	m, err := WMean(w, 1, data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(Mean(data, 1), m, "WMean", t)
+	v, err := WVariance(w, 1, data, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(Variance(data, 1), v, "WVariance", t)
+
+	// Integer weights are the same as repeated data
+	w = []float64{2, 1, 1, 3}
+	rep := []float64{1, 1, 2, 3, 4, 4, 4}
+	m, _ = WMean(w, 1, data, 1)
+	eps.EqFloat64(Mean(rep, 1), m, "WMean repeated", t)
+	// Symmetric weights about a symmetric distribution have no skewness
+	s, _ := WSkew([]float64{1, 2, 2, 1}, 1, data, 1)
+	eps.EqFloat64(0, s, "WSkew symmetric", t)
+	sd, _ := WSd(w, 1, data, 1)
+	if sd <= 0 {
+		t.Errorf("Expected a positive standard deviation, got %f", sd)
+	}
+
+	if _, err = WMean(w[1:], 1, data, 1); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
@@ -0,0 +1,80 @@
+// Package sum wraps the GSL Levin u-transform series acceleration.
+//
+// This is useful for slowly converging (or even divergent, asymptotic) sums, eg. in
+// Bessel function expansions.
+package sum
+
+/*
+#cgo pkg-config: gsl
+
+#include <gsl/gsl_sum.h>
+*/
+import "C"
+
+import (
+	"errors"
+
+	"github.com/npadmana/npgo/gsl"
+)
+
+// Accel is the result of an accelerated sum
+type Accel struct {
+	gsl.Result         // The accelerated sum, and its error estimate
+	TermsUsed  int     // The number of terms used
+	Plain      float64 // The plain sum of the terms used
+}
+
+// Levin accelerates the sum of terms with the Levin u-transform, and returns
+// the extrapolated limit of the series.
+func Levin(terms []float64) (Accel, error) {
+	if len(terms) == 0 {
+		return Accel{}, errors.New("Need at least one term")
+	}
+	w := C.gsl_sum_levin_u_alloc(C.size_t(len(terms)))
+	defer C.gsl_sum_levin_u_free(w)
+	var y, err C.double
+	ret := C.gsl_sum_levin_u_accel((*C.double)(&terms[0]), C.size_t(len(terms)), w, &y, &err)
+	acc := Accel{
+		Result:    gsl.Result{Res: float64(y), Err: float64(err)},
+		TermsUsed: int(w.terms_used),
+		Plain:     float64(w.sum_plain),
+	}
+	if ret != 0 {
+		return acc, gsl.Errno(ret)
+	}
+	return acc, nil
+}
+
+// LevinTrunc is as Levin, but uses the faster truncated transform. The error estimate
+// is only a rough one, from the variation between successive approximations.
+func LevinTrunc(terms []float64) (Accel, error) {
+	if len(terms) == 0 {
+		return Accel{}, errors.New("Need at least one term")
+	}
+	w := C.gsl_sum_levin_utrunc_alloc(C.size_t(len(terms)))
+	defer C.gsl_sum_levin_utrunc_free(w)
+	var y, err C.double
+	ret := C.gsl_sum_levin_utrunc_accel((*C.double)(&terms[0]), C.size_t(len(terms)), w, &y, &err)
+	acc := Accel{
+		Result:    gsl.Result{Res: float64(y), Err: float64(err)},
+		TermsUsed: int(w.terms_used),
+		Plain:     float64(w.sum_plain),
+	}
+	if ret != 0 {
+		return acc, gsl.Errno(ret)
+	}
+	return acc, nil
+}
+
+// Series accelerates the sum of term(0), term(1), ..., term(n-1). term is a gsl.F,
+// called with the (integer) index of the term.
+func Series(term gsl.F, n int) (Accel, error) {
+	if n < 1 {
+		return Accel{}, errors.New("Need at least one term")
+	}
+	terms := make([]float64, n)
+	for i := range terms {
+		terms[i] = term(float64(i))
+	}
+	return Levin(terms)
+}
@@ -0,0 +1,56 @@
+package sum
+
+import (
+	"math"
+	"testing"
+
+	"github.com/npadmana/npgo/nptest"
+)
+
+func TestLevin(t *testing.T) {
+	// zeta(2) = pi^2/6 converges very slowly
+	n := 20
+	terms := make([]float64, n)
+	for i := range terms {
+		terms[i] = 1 / float64((i+1)*(i+1))
+	}
+	zeta2 := math.Pi * math.Pi / 6
+
+	acc, err := Levin(terms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps := nptest.NewEps(1.e-10, 1.e-10)
+	eps.EqFloat64(zeta2, acc.Res, "Levin", t)
+	if acc.Err > 1.e-8 {
+		t.Errorf("Large error estimate : %e", acc.Err)
+	}
+	if math.Abs(acc.Plain-zeta2) < 0.01 {
+		t.Errorf("Plain sum unexpectedly accurate : %f", acc.Plain)
+	}
+	if acc.TermsUsed < 1 || acc.TermsUsed > n {
+		t.Errorf("Incorrect terms used : %d", acc.TermsUsed)
+	}
+
+	acc, err = LevinTrunc(terms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(acc.Res-zeta2) > 1.e-6 {
+		t.Errorf("LevinTrunc : expected %f, got %f", zeta2, acc.Res)
+	}
+
+	if _, err = Levin(nil); err == nil {
+		t.Error("Expected an error, none reported")
+	}
+}
+
+func TestSeries(t *testing.T) {
+	// log(2) = 1 - 1/2 + 1/3 - ...
+	acc, err := Series(func(n float64) float64 { return math.Pow(-1, n) / (n + 1) }, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eps := nptest.NewEps(1.e-12, 1.e-12)
+	eps.EqFloat64(math.Log(2), acc.Res, "Series", t)
+}
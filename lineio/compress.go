@@ -0,0 +1,98 @@
+package lineio
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// ZstdCommand is the external command used to decompress .zst files, since there
+// is no zstd decoder in the standard library. It is run as ZstdCommand -dc -- fn.
+var ZstdCommand = "zstd"
+
+// Open opens the file fn for reading, decompressing it based on its extension :
+//
+//	.gz  : gzip
+//	.bz2 : bzip2
+//	.zst : zstd, using ZstdCommand
+//
+// Other files are read as is.
+func Open(fn string) (io.ReadCloser, error) {
+	switch filepath.Ext(fn) {
+	case ".zst":
+		return openZstd(fn)
+	case ".gz":
+		ff, err := os.Open(fn)
+		if err != nil {
+			return nil, err
+		}
+		gz, err := gzip.NewReader(ff)
+		if err != nil {
+			ff.Close()
+			return nil, err
+		}
+		return &readCloser{gz, []io.Closer{gz, ff}}, nil
+	case ".bz2":
+		ff, err := os.Open(fn)
+		if err != nil {
+			return nil, err
+		}
+		return &readCloser{bzip2.NewReader(ff), []io.Closer{ff}}, nil
+	}
+	return os.Open(fn)
+}
+
+// readCloser closes a stack of readers, innermost first
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if err1 := c.Close(); err == nil {
+			err = err1
+		}
+	}
+	return err
+}
+
+func openZstd(fn string) (io.ReadCloser, error) {
+	// Check the file exists first, for a sensible error message
+	if _, err := os.Stat(fn); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(ZstdCommand, "-dc", "--", fn)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &zstdReader{out, cmd, &stderr}, nil
+}
+
+// zstdReader reads from a zstd process, and waits for it on Close
+type zstdReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+func (z *zstdReader) Close() error {
+	// Drain any unread output, so that the process can exit
+	io.Copy(io.Discard, z.ReadCloser)
+	if err := z.cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed : %v : %s", ZstdCommand, err, bytes.TrimSpace(z.stderr.Bytes()))
+	}
+	return nil
+}
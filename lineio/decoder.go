@@ -0,0 +1,232 @@
+package lineio
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// Decoder is a LineIOType that decodes each line into a new element of a slice of
+// structs, using struct tags to map columns onto fields :
+//
+//	type RDZW struct {
+//		RA   float64 `col:"0"`   // Column 0, counting from zero
+//		Dec  float64 `col:"dec"` // The column named dec in the header
+//		Name string  `col:"5"`
+//		Tmp  float64             // Untagged fields (or col:"-") are left zero
+//	}
+//
+// Supported field types are float32/64, signed and unsigned ints, bool and string.
+// Columns that are not referenced are skipped.
+//
+// If any columns are named, the first line is taken to be the header, unless SetHeader
+// is called first. Since Parse strips comments, a commented header must be passed in
+// with SetHeader.
+//
+// Decoder also satisfies ParallelLineIOType; with named columns, SetHeader must be called
+// before parallel parsing.
+type Decoder struct {
+	Dialect Dialect // How lines are split into columns; the default splits on spaces and tabs
+
+	slice      reflect.Value // The slice being filled
+	zero       reflect.Value
+	fields     []field
+	needHeader bool
+	chunk      bool // Parsing a chunk in parallel, so cannot read the header
+	toks       [][]byte
+}
+
+type field struct {
+	name   string // Column name, if not given by number
+	col    int
+	offset uintptr
+	kind   reflect.Kind
+}
+
+// NewDecoder returns a decoder that appends to *arr, which must be a pointer to a slice
+// of structs.
+func NewDecoder(arr interface{}) (*Decoder, error) {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice || v.Elem().Type().Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("NewDecoder needs a pointer to a slice of structs, got %T", arr)
+	}
+	d := new(Decoder)
+	d.slice = v.Elem()
+	typ := d.slice.Type().Elem()
+	d.zero = reflect.Zero(typ)
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag := sf.Tag.Get("col")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		f := field{col: -1, offset: sf.Offset, kind: sf.Type.Kind()}
+		switch f.kind {
+		case reflect.Float32, reflect.Float64, reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("Unsupported type %s for field %s", sf.Type, sf.Name)
+		}
+		if n, err := strconv.Atoi(tag); err == nil {
+			if n < 0 {
+				return nil, fmt.Errorf("Negative column %d for field %s", n, sf.Name)
+			}
+			f.col = n
+		} else {
+			f.name = tag
+			d.needHeader = true
+		}
+		d.fields = append(d.fields, f)
+	}
+	if len(d.fields) == 0 {
+		return nil, fmt.Errorf("No col tags found in %s", typ)
+	}
+	return d, nil
+}
+
+// SetHeader sets the column names, and resolves the named columns.
+func (d *Decoder) SetHeader(names []string) error {
+	for i := range d.fields {
+		f := &d.fields[i]
+		if f.name == "" {
+			continue
+		}
+		f.col = -1
+		for j, n := range names {
+			if n == f.name {
+				f.col = j
+				break
+			}
+		}
+		if f.col == -1 {
+			return fmt.Errorf("Column %s not found in header", f.name)
+		}
+	}
+	d.needHeader = false
+	return nil
+}
+
+// Add decodes a single line
+func (d *Decoder) Add(b []byte) error {
+	d.toks = d.Dialect.Split(b, d.toks)
+	toks := d.toks
+	if d.needHeader {
+		if d.chunk {
+			return errors.New("Named columns need SetHeader before parallel parsing")
+		}
+		names := make([]string, len(toks))
+		for i, t := range toks {
+			names[i] = string(t)
+		}
+		return d.SetHeader(names)
+	}
+
+	// Append a zero element
+	n := d.slice.Len()
+	if n < d.slice.Cap() {
+		d.slice.SetLen(n + 1)
+		d.slice.Index(n).Set(d.zero)
+	} else {
+		d.slice.Set(reflect.Append(d.slice, d.zero))
+	}
+	ptr := unsafe.Pointer(d.slice.Index(n).UnsafeAddr())
+
+	for _, f := range d.fields {
+		if f.col >= len(toks) {
+			d.slice.SetLen(n)
+			return fmt.Errorf("Column %d not found in line with %d columns", f.col, len(toks))
+		}
+		if err := f.set(unsafe.Add(ptr, f.offset), toks[f.col]); err != nil {
+			d.slice.SetLen(n)
+			return err
+		}
+	}
+	return nil
+}
+
+func (f field) set(p unsafe.Pointer, tok []byte) error {
+	s := unsafeString(tok)
+	switch f.kind {
+	case reflect.Float64:
+		v, err := parseFloat64(tok)
+		*(*float64)(p) = v
+		return err
+	case reflect.Float32:
+		v, err := parseFloat32(tok)
+		*(*float32)(p) = v
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, bitSize(f.kind))
+		switch f.kind {
+		case reflect.Int:
+			*(*int)(p) = int(v)
+		case reflect.Int8:
+			*(*int8)(p) = int8(v)
+		case reflect.Int16:
+			*(*int16)(p) = int16(v)
+		case reflect.Int32:
+			*(*int32)(p) = int32(v)
+		case reflect.Int64:
+			*(*int64)(p) = v
+		}
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, bitSize(f.kind))
+		switch f.kind {
+		case reflect.Uint:
+			*(*uint)(p) = uint(v)
+		case reflect.Uint8:
+			*(*uint8)(p) = uint8(v)
+		case reflect.Uint16:
+			*(*uint16)(p) = uint16(v)
+		case reflect.Uint32:
+			*(*uint32)(p) = uint32(v)
+		case reflect.Uint64:
+			*(*uint64)(p) = v
+		}
+		return err
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		*(*bool)(p) = v
+		return err
+	case reflect.String:
+		// The line buffer is reused, so copy
+		*(*string)(p) = string(tok)
+	}
+	return nil
+}
+
+func bitSize(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 8
+	case reflect.Int16, reflect.Uint16:
+		return 16
+	case reflect.Int32, reflect.Uint32:
+		return 32
+	}
+	return 64
+}
+
+// Empty returns a decoder for a new slice of the same type, for parallel parsing
+func (d *Decoder) Empty() ParallelLineIOType {
+	d1 := *d
+	d1.slice = reflect.New(d.slice.Type()).Elem()
+	d1.fields = append([]field(nil), d.fields...)
+	d1.chunk = true
+	d1.toks = nil
+	return &d1
+}
+
+// Merge appends the elements decoded by a decoder returned by Empty
+func (d *Decoder) Merge(a ParallelLineIOType) error {
+	d1, ok := a.(*Decoder)
+	if !ok || d1.slice.Type() != d.slice.Type() {
+		return errors.New("Cannot merge decoders of different types")
+	}
+	d.slice.Set(reflect.AppendSlice(d.slice, d1.slice))
+	return nil
+}
@@ -0,0 +1,197 @@
+package lineio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type decodeRow struct {
+	RA    float64 `col:"0"`
+	Dec   float32 `col:"1"`
+	ID    int64   `col:"3"`
+	Flag  bool    `col:"4"`
+	Name  string  `col:"5"`
+	N     uint8   `col:"6"`
+	Extra float64
+	Skip  float64 `col:"-"`
+}
+
+func TestDecoder(t *testing.T) {
+	text := "1.5 -2.5 99 12 true gal1 7\n3.5 4.5 99 -3 0 gal2 255 extra\n"
+	var arr []decodeRow
+	d, err := NewDecoder(&arr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ReadReader(strings.NewReader(text), d); err != nil {
+		t.Fatal(err)
+	}
+	expected := []decodeRow{
+		{RA: 1.5, Dec: -2.5, ID: 12, Flag: true, Name: "gal1", N: 7},
+		{RA: 3.5, Dec: 4.5, ID: -3, Flag: false, Name: "gal2", N: 255},
+	}
+	if len(arr) != len(expected) {
+		t.Fatalf("Expected %d rows, got %d", len(expected), len(arr))
+	}
+	for i := range expected {
+		if arr[i] != expected[i] {
+			t.Errorf("Row %d : expected %+v, got %+v", i, expected[i], arr[i])
+		}
+	}
+
+	// Errors leave the slice unchanged
+	for _, line := range []string{"1 2 3", "1 2 3 4 maybe x 1", "1 2 3 4 1 x 256", "x 2 3 4 1 x 1"} {
+		if err = d.Add([]byte(line)); err == nil {
+			t.Errorf("%s : an error was expected", line)
+		}
+	}
+	if len(arr) != 2 {
+		t.Errorf("Expected 2 rows after errors, got %d", len(arr))
+	}
+
+	if _, err = NewDecoder(arr); err == nil {
+		t.Error("An error was expected")
+	}
+	if _, err = NewDecoder(&[]badRow{}); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+type badRow struct {
+	X complex128 `col:"0"`
+}
+
+type namedRow struct {
+	Z  float64 `col:"z"`
+	RA float64 `col:"ra"`
+	W  int     `col:"3"`
+}
+
+func TestDecoderHeader(t *testing.T) {
+	var arr []namedRow
+	d, _ := NewDecoder(&arr)
+	d.Dialect = CSV
+	if err := LineIOParamsCSV.ParseReader(strings.NewReader("ra,dec,z,w\n1,2,3,4\n5, ,6,7,8\n"), d); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 2 || arr[0] != (namedRow{3, 1, 4}) || arr[1] != (namedRow{6, 5, 7}) {
+		t.Errorf("Incorrect rows : %+v", arr)
+	}
+
+	arr = nil
+	d, _ = NewDecoder(&arr)
+	if err := d.SetHeader([]string{"ra", "dec"}); err == nil {
+		t.Error("An error was expected for a missing column")
+	}
+}
+
+type emptyRow struct {
+	A string  `col:"0"`
+	B float64 `col:"1"`
+	C string  `col:"2"`
+}
+
+func TestDecoderEmptyFields(t *testing.T) {
+	for _, tc := range []struct {
+		params LineIOParams
+		text   string
+	}{
+		{LineIOParamsTSV, "\t2\t\nx\t3\ty\r\n\t4\t \n"},
+		{LineIOParamsCSV, ",2,\nx,3,y\r\n ,4, \n"},
+	} {
+		var arr []emptyRow
+		d, _ := NewDecoder(&arr)
+		d.Dialect = tc.params.Dialect
+		if err := tc.params.ParseReader(strings.NewReader(tc.text), d); err != nil {
+			t.Fatalf("%q : %v", tc.text, err)
+		}
+		expected := []emptyRow{{"", 2, ""}, {"x", 3, "y"}, {"", 4, ""}}
+		if len(arr) != len(expected) {
+			t.Fatalf("%q : expected %d rows, got %+v", tc.text, len(expected), arr)
+		}
+		for i := range expected {
+			if arr[i] != expected[i] {
+				t.Errorf("%q : row %d expected %+v, got %+v", tc.text, i, expected[i], arr[i])
+			}
+		}
+	}
+}
+
+func TestDecoderParallel(t *testing.T) {
+	var sb strings.Builder
+	n := 500
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, "%d %d %d %d\n", i, 2*i, 0, i%5)
+	}
+	fn := filepath.Join(t.TempDir(), "data.dat")
+	if err := os.WriteFile(fn, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var arr []namedRow
+	d, _ := NewDecoder(&arr)
+	if err := d.SetHeader([]string{"ra", "z"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadParallel(fn, 4, d); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != n {
+		t.Fatalf("Expected %d rows, got %d", n, len(arr))
+	}
+	for i, r := range arr {
+		if r.RA != float64(i) || r.Z != float64(2*i) {
+			t.Errorf("Row %d out of order : %+v", i, r)
+			break
+		}
+	}
+
+	// Without a header
+	arr = nil
+	d, _ = NewDecoder(&arr)
+	if err := ReadParallel(fn, 4, d); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+type benchRow struct {
+	RA, Dec, Z, W float64
+}
+
+type benchArr []benchRow
+
+func (arr *benchArr) Add(s []byte) error {
+	var x benchRow
+	if err := ParseToFloat64s(s, []byte{' '}, &x.RA, &x.Dec, &x.Z, &x.W); err != nil {
+		return err
+	}
+	*arr = append(*arr, x)
+	return nil
+}
+
+type benchTagged struct {
+	RA  float64 `col:"0"`
+	Dec float64 `col:"1"`
+	Z   float64 `col:"2"`
+	W   float64 `col:"3"`
+}
+
+var benchLine = []byte("187.2345671 -12.3456789 0.5734512 1.0347")
+
+func BenchmarkAddHandwritten(b *testing.B) {
+	var arr benchArr
+	for i := 0; i < b.N; i++ {
+		arr.Add(benchLine)
+	}
+}
+
+func BenchmarkAddDecoder(b *testing.B) {
+	var arr []benchTagged
+	d, _ := NewDecoder(&arr)
+	for i := 0; i < b.N; i++ {
+		d.Add(benchLine)
+	}
+}
@@ -0,0 +1,179 @@
+package lineio
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// Dialect describes how a line is split into fields
+type Dialect struct {
+	Delim byte // Field delimiter; if 0, fields are separated by runs of spaces and tabs
+	Quote byte // If non-zero, fields may be quoted, and delimiters and comments inside quotes are ignored
+}
+
+// Common dialects. In CSV and TSV files, every delimiter separates a field, so empty
+// fields are preserved.
+var (
+	Whitespace = Dialect{}
+	CSV        = Dialect{Delim: ',', Quote: '"'}
+	TSV        = Dialect{Delim: '\t', Quote: '"'}
+)
+
+// Split splits line into fields, appending them to fields[:0], and returns the result.
+// Pass in the previous result to avoid allocations.
+//
+// Leading and trailing whitespace is trimmed from delimited fields. The quotes around
+// a quoted field are removed; a doubled quote inside a quoted field is an escaped quote,
+// and such fields are copied.
+func (d Dialect) Split(line []byte, fields [][]byte) [][]byte {
+	fields = fields[:0]
+	if d.Delim == 0 && d.Quote == 0 {
+		start := -1
+		for i, c := range line {
+			if c == ' ' || c == '\t' {
+				if start >= 0 {
+					fields = append(fields, line[start:i])
+					start = -1
+				}
+			} else if start < 0 {
+				start = i
+			}
+		}
+		if start >= 0 {
+			fields = append(fields, line[start:])
+		}
+		return fields
+	}
+
+	for {
+		if d.Delim == 0 {
+			line = bytes.TrimLeft(line, " \t")
+			if len(line) == 0 {
+				return fields
+			}
+		}
+		var f []byte
+		f, line = d.next(line)
+		fields = append(fields, f)
+		if line == nil {
+			return fields
+		}
+	}
+}
+
+// next returns the next field, and the rest of the line after the delimiter.
+// rest is nil if this is the last field.
+func (d Dialect) next(line []byte) (f, rest []byte) {
+	isDelim := func(c byte) bool {
+		if d.Delim == 0 {
+			return c == ' ' || c == '\t'
+		}
+		return c == d.Delim
+	}
+	if d.Delim != 0 {
+		line = bytes.TrimLeftFunc(line, d.isSpace)
+	}
+
+	if d.Quote != 0 && len(line) > 0 && line[0] == d.Quote {
+		// Quoted field
+		escaped := false
+		i := 1
+		for ; i < len(line); i++ {
+			if line[i] != d.Quote {
+				continue
+			}
+			if i+1 < len(line) && line[i+1] == d.Quote {
+				escaped = true
+				i++
+				continue
+			}
+			break
+		}
+		f = line[1:min(i, len(line))]
+		if escaped {
+			f = bytes.ReplaceAll(f, []byte{d.Quote, d.Quote}, []byte{d.Quote})
+		}
+		// Skip to the delimiter
+		for i++; i < len(line) && !isDelim(line[i]); i++ {
+		}
+		if i >= len(line) {
+			return f, nil
+		}
+		return f, line[i+1:]
+	}
+
+	i := 0
+	for i < len(line) && !isDelim(line[i]) {
+		i++
+	}
+	f = line[:i]
+	if d.Delim != 0 {
+		f = bytes.TrimRightFunc(f, d.isSpace)
+	}
+	if i >= len(line) {
+		return f, nil
+	}
+	return f, line[i+1:]
+}
+
+// trim removes leading and trailing whitespace from line, except for the delimiter, so
+// that empty first and last fields are preserved.
+func (d Dialect) trim(line []byte) []byte {
+	if d.Delim == 0 {
+		return bytes.TrimSpace(line)
+	}
+	return bytes.TrimFunc(line, func(r rune) bool {
+		return unicode.IsSpace(r) && r != rune(d.Delim)
+	})
+}
+
+// isSpace is true for spaces and tabs, unless they are the delimiter
+func (d Dialect) isSpace(r rune) bool {
+	return (r == ' ' || r == '\t') && r != rune(d.Delim)
+}
+
+// stripComment removes the comment from line. A comment starts at any of the characters
+// in chars, or at any of the prefixes. If atStart, only lines that begin with a comment
+// are stripped. Comments inside quotes are ignored if quote is non-zero.
+func stripComment(line []byte, chars string, prefixes []string, atStart bool, quote byte) []byte {
+	if atStart {
+		if isComment(line, chars, prefixes) {
+			return line[:0]
+		}
+		return line
+	}
+	if quote == 0 && len(prefixes) == 0 {
+		if n := bytes.IndexAny(line, chars); n != -1 {
+			return line[:n]
+		}
+		return line
+	}
+	inQuote := false
+	for i, c := range line {
+		if quote != 0 && c == quote {
+			inQuote = !inQuote
+			continue
+		}
+		if !inQuote && isComment(line[i:], chars, prefixes) {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// isComment returns true if line starts with a comment
+func isComment(line []byte, chars string, prefixes []string) bool {
+	if len(line) == 0 {
+		return false
+	}
+	if strings.IndexByte(chars, line[0]) != -1 {
+		return true
+	}
+	for _, p := range prefixes {
+		if len(p) > 0 && strings.HasPrefix(unsafeString(line), p) {
+			return true
+		}
+	}
+	return false
+}
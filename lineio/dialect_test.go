@@ -0,0 +1,97 @@
+package lineio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		d        Dialect
+		line     string
+		expected []string
+	}{
+		{Whitespace, "  1 \t 2  3 ", []string{"1", "2", "3"}},
+		{CSV, "1, 2 ,,3", []string{"1", "2", "", "3"}},
+		{CSV, `"a, b",c,"say ""hi""", d`, []string{"a, b", "c", `say "hi"`, "d"}},
+		{CSV, `1,`, []string{"1", ""}},
+		{TSV, "a b\tc\t\td", []string{"a b", "c", "", "d"}},
+		{Dialect{Quote: '\''}, "'a b'  c", []string{"a b", "c"}},
+	}
+	var fields [][]byte
+	for _, tt := range tests {
+		fields = tt.d.Split([]byte(tt.line), fields)
+		if len(fields) != len(tt.expected) {
+			t.Errorf("%q : expected %q, got %q", tt.line, tt.expected, fields)
+			continue
+		}
+		for i := range fields {
+			if string(fields[i]) != tt.expected[i] {
+				t.Errorf("%q : expected %q, got %q", tt.line, tt.expected, fields)
+				break
+			}
+		}
+	}
+}
+
+// lines collects the lines passed to Add
+type lines []string
+
+func (l *lines) Add(b []byte) error {
+	*l = append(*l, string(b))
+	return nil
+}
+
+func TestComments(t *testing.T) {
+	text := "header 1\nheader 2\n1 2 # c1\n// c2\n3 // 4\n\"a # b\",c ; d\n; c3\n"
+	tests := []struct {
+		l        LineIOParams
+		expected []string
+	}{
+		{LineIOParams{Comment: "#;"}, []string{"header 1", "header 2", "1 2", "// c2", "3 // 4", "\"a"}},
+		{LineIOParams{Comment: "#", CommentPrefixes: []string{"//"}, SkipLines: 2}, []string{"1 2", "3", "\"a", "; c3"}},
+		{LineIOParams{Comment: ";", CommentPrefixes: []string{"//"}, CommentAtStart: true, SkipLines: 1},
+			[]string{"header 2", "1 2 # c1", "3 // 4", "\"a # b\",c ; d"}},
+		{LineIOParams{Comment: "#;", SkipLines: 5, Dialect: CSV}, []string{"\"a # b\",c"}},
+	}
+	for i, tt := range tests {
+		var l lines
+		if err := tt.l.ParseReader(strings.NewReader(text), &l); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(l, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("%d : expected %q, got %q", i, tt.expected, l)
+		}
+	}
+
+	// Skipped header lines in parallel
+	fn := filepath.Join(t.TempDir(), "data.dat")
+	os.WriteFile(fn, []byte("x y\nz w\n1 2\n3 4\n5 6\n"), 0644)
+	var a xArr
+	if err := (LineIOParams{SkipLines: 2}).ParseParallel(fn, 3, &a); err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 3 || a[0] != 1 || a[2] != 5 {
+		t.Errorf("Incorrect data %v", a)
+	}
+}
+
+func TestMultipleSeparators(t *testing.T) {
+	var x, y, z float64
+	if err := ParseToFloat64s([]byte("1.5, 2\t3"), []byte(" ,\t"), &x, &y, &z); err != nil {
+		t.Fatal(err)
+	}
+	eps.EqFloat64(1.5, x, "", t)
+	eps.EqFloat64(2, y, "", t)
+	eps.EqFloat64(3, z, "", t)
+
+	var arr []float64
+	if err := ParseToFloat64Arr([]byte("1;2 ; 3"), []byte("; "), &arr, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 3 || arr[2] != 3 {
+		t.Errorf("Incorrect array %v", arr)
+	}
+}
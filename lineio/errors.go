@@ -0,0 +1,55 @@
+package lineio
+
+import (
+	"fmt"
+)
+
+// maxErrorText is the maximum length of the line stored in a ParseError
+const maxErrorText = 256
+
+// ParseError records where parsing a line failed
+type ParseError struct {
+	Filename string // Empty if parsing from an io.Reader
+	Line     int    // Line number, counting from 1
+	Offset   int64  // Byte offset of the start of the line
+	Text     string // The line, truncated to 256 bytes
+	Err      error  // The error returned by Add
+}
+
+func newParseError(fn string, line int, offset int64, text []byte, err error) *ParseError {
+	if len(text) > maxErrorText {
+		text = append(text[:maxErrorText:maxErrorText], "..."...)
+	}
+	return &ParseError{Filename: fn, Line: line, Offset: offset, Text: string(text), Err: err}
+}
+
+func (e *ParseError) Error() string {
+	fn := e.Filename
+	if fn == "" {
+		fn = "<reader>"
+	}
+	return fmt.Sprintf("%s:%d (byte %d): %v : %q", fn, e.Line, e.Offset, e.Err, e.Text)
+}
+
+// Unwrap returns the error returned by Add
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors collects the lines skipped when LineIOParams.MaxBadLines > 0.
+// If Aborted is set, there were more than MaxBadLines bad lines, and parsing
+// stopped at the last one.
+type ParseErrors struct {
+	Errors  []*ParseError
+	Aborted bool
+}
+
+func (e *ParseErrors) Error() string {
+	if len(e.Errors) == 0 {
+		return "no parse errors"
+	}
+	if e.Aborted {
+		return fmt.Sprintf("too many bad lines (%d), aborted; first : %v", len(e.Errors), e.Errors[0])
+	}
+	return fmt.Sprintf("%d bad lines skipped; first : %v", len(e.Errors), e.Errors[0])
+}
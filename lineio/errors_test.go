@@ -0,0 +1,87 @@
+package lineio
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const badText = "1 2\n# comment\n3 x\n5 6\n7\n9 10\n"
+
+func TestParseError(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "bad.dat")
+	if err := os.WriteFile(fn, []byte(badText), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var a xArr
+	err := Read(fn, &a)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if perr.Filename != fn || perr.Line != 3 || perr.Offset != 14 || perr.Text != "3 x" {
+		t.Errorf("Incorrect ParseError : %+v", perr)
+	}
+	var nerr *strconv.NumError
+	if !errors.As(err, &nerr) {
+		t.Errorf("Expected to unwrap to a NumError, got %v", perr.Err)
+	}
+
+	// From a reader, with more than one chunk
+	for _, nchunk := range []int{0, 1, 3} {
+		a = nil
+		if nchunk == 0 {
+			err = ReadReader(strings.NewReader(badText), &a)
+		} else {
+			err = ReadParallel(fn, nchunk, &a)
+		}
+		if !errors.As(err, &perr) || perr.Line != 3 || perr.Offset != 14 {
+			t.Errorf("%d chunks : incorrect error %v", nchunk, err)
+		}
+	}
+}
+
+func TestLenient(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "bad.dat")
+	if err := os.WriteFile(fn, []byte(badText), 0644); err != nil {
+		t.Fatal(err)
+	}
+	l := LineIOParams{Comment: "#", MaxBadLines: 2}
+
+	for _, nchunk := range []int{0, 1, 4} {
+		var a xArr
+		var err error
+		if nchunk == 0 {
+			err = l.Parse(fn, &a)
+		} else {
+			err = l.ParseParallel(fn, nchunk, &a)
+		}
+		perrs, ok := err.(*ParseErrors)
+		if !ok {
+			t.Fatalf("%d chunks : expected ParseErrors, got %v", nchunk, err)
+		}
+		if perrs.Aborted || len(perrs.Errors) != 2 {
+			t.Errorf("%d chunks : incorrect summary %v", nchunk, perrs)
+		}
+		if perrs.Errors[0].Line != 3 || perrs.Errors[1].Line != 5 || perrs.Errors[1].Offset != 22 {
+			t.Errorf("%d chunks : incorrect errors %v %v", nchunk, perrs.Errors[0], perrs.Errors[1])
+		}
+		if len(a) != 3 || a[2] != 9 {
+			t.Errorf("%d chunks : incorrect data %v", nchunk, a)
+		}
+	}
+
+	l.MaxBadLines = 1
+	var a xArr
+	err := l.Parse(fn, &a)
+	if perrs, ok := err.(*ParseErrors); !ok || !perrs.Aborted || len(perrs.Errors) != 2 {
+		t.Errorf("Expected an aborted parse, got %v", err)
+	}
+	if len(a) != 2 {
+		t.Errorf("Parsing should stop at line 5, got %v", a)
+	}
+}
@@ -0,0 +1,180 @@
+package lineio
+
+import (
+	"math"
+	"strconv"
+)
+
+// Powers of 10 that are exactly representable as float64
+var pow10f64 = [...]float64{1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10,
+	1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20, 1e21, 1e22}
+
+// parseDecimal parses a plain decimal float, [+-]ddd[.ddd][(e|E)[+-]ddd], into a
+// mantissa and a base 10 exponent. ok is false for anything else (hex floats, NaN, Inf,
+// more than 19 digits, etc.), which is left to strconv.
+func parseDecimal(b []byte) (mant uint64, exp int, neg, ok bool) {
+	i := 0
+	if i < len(b) && (b[i] == '+' || b[i] == '-') {
+		neg = b[i] == '-'
+		i++
+	}
+	ndigits, nmant := 0, 0
+	sawdot := false
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c >= '0' && c <= '9':
+			ndigits++
+			if c == '0' && nmant == 0 {
+				// Leading zeros are not significant
+				if sawdot {
+					exp--
+				}
+				continue
+			}
+			if nmant == 19 {
+				return 0, 0, false, false
+			}
+			mant = mant*10 + uint64(c-'0')
+			nmant++
+			if sawdot {
+				exp--
+			}
+		case c == '.' && !sawdot:
+			sawdot = true
+		default:
+			goto expt
+		}
+	}
+expt:
+	if ndigits == 0 {
+		return 0, 0, false, false
+	}
+	if i < len(b) {
+		if b[i] != 'e' && b[i] != 'E' {
+			return 0, 0, false, false
+		}
+		i++
+		eneg := false
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			eneg = b[i] == '-'
+			i++
+		}
+		if i == len(b) || len(b)-i > 4 {
+			return 0, 0, false, false
+		}
+		e := 0
+		for ; i < len(b); i++ {
+			c := b[i]
+			if c < '0' || c > '9' {
+				return 0, 0, false, false
+			}
+			e = e*10 + int(c-'0')
+		}
+		if eneg {
+			e = -e
+		}
+		exp += e
+	}
+	return mant, exp, neg, true
+}
+
+// parseFloat64 is strconv.ParseFloat(string(b), 64), with a fast path for the common
+// case of short decimal numbers. The fast path is only used when the result is exact
+// (a mantissa < 2^53 scaled by an exact power of 10), so the results are identical.
+func parseFloat64(b []byte) (float64, error) {
+	if mant, exp, neg, ok := parseDecimal(b); ok && mant < 1<<53 {
+		var f float64
+		switch {
+		case mant == 0:
+			f = 0
+		case exp >= 0 && exp < len(pow10f64):
+			f = float64(mant) * pow10f64[exp]
+		case exp < 0 && -exp < len(pow10f64):
+			f = float64(mant) / pow10f64[-exp]
+		default:
+			ok = false
+		}
+		if ok {
+			if neg {
+				f = -f
+			}
+			return f, nil
+		}
+	}
+	return strconv.ParseFloat(unsafeString(b), 64)
+}
+
+// parseFloat32 is the float32 version of parseFloat64. The fast path rounds the (correctly
+// rounded) float64 value to float32. This double rounding is only wrong if the float64
+// value lies exactly halfway between two float32s, which is checked for.
+func parseFloat32(b []byte) (float32, error) {
+	if mant, exp, neg, ok := parseDecimal(b); ok && mant < 1<<53 && exp > -len(pow10f64) && exp < len(pow10f64) {
+		var f float64
+		switch {
+		case mant == 0:
+			f = 0
+		case exp >= 0:
+			f = float64(mant) * pow10f64[exp]
+		default:
+			f = float64(mant) / pow10f64[-exp]
+		}
+		if math.Float64bits(f)&(1<<29-1) != 1<<28 {
+			if neg {
+				f = -f
+			}
+			return float32(f), nil
+		}
+	}
+	v, err := strconv.ParseFloat(unsafeString(b), 32)
+	return float32(v), err
+}
+
+// ParseToFloat32s is the float32 version of ParseToFloat64s
+func ParseToFloat32s(s, sep []byte, args ...*float32) error {
+	iarg := 0
+	var err error
+	var val float32
+	var tok []byte
+	for tok, s = nextToken(s, sep); len(tok) > 0; tok, s = nextToken(s, sep) {
+		if val, err = parseFloat32(tok); err != nil {
+			return err
+		}
+		if iarg >= len(args) {
+			return errNumElements
+		}
+		*args[iarg] = val
+		iarg++
+	}
+	if iarg != len(args) {
+		return errNumElements
+	}
+	return nil
+}
+
+// ParseToFloat32Arr is the float32 version of ParseToFloat64Arr
+func ParseToFloat32Arr(s, sep []byte, arr *[]float32, grow bool) error {
+	iarg := 0
+	nargs := len(*arr)
+	var err error
+	var val float32
+	var tok []byte
+	for tok, s = nextToken(s, sep); len(tok) > 0; tok, s = nextToken(s, sep) {
+		if val, err = parseFloat32(tok); err != nil {
+			return err
+		}
+		if iarg >= nargs {
+			if !grow {
+				return errNumElements
+			}
+			*arr = append(*arr, val)
+		} else {
+			(*arr)[iarg] = val
+		}
+		iarg++
+	}
+	if iarg != len(*arr) {
+		return errNumElements
+	}
+	return nil
+}
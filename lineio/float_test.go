@@ -0,0 +1,111 @@
+package lineio
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+var floatTests = []string{
+	"0", "-0", "+1", "1.", ".5", "-.5e1", "1.234", "7.9", "-2.3", "1.2e4", "1.2e-4", "1E5",
+	"187.2345671", "-12.3456789", "0.000001234", "123456789012345678", "1234567890123456789012",
+	"9007199254740993", "1e22", "1e23", "1e-22", "1.7976931348623157e308", "4.9e-324", "1e400",
+	"0x1p-2", "NaN", "-Inf", "inf", "1e", "1e+", "e5", ".", "-", "1.2.3", "1_0", "12a", "", "1e00005",
+	"0.1", "0.3", "3.4028235e38", "16777217", "1.0000001",
+}
+
+func TestParseFloat(t *testing.T) {
+	for _, s := range floatTests {
+		v, err := parseFloat64([]byte(s))
+		v1, err1 := strconv.ParseFloat(s, 64)
+		if (err == nil) != (err1 == nil) || (err == nil && math.Float64bits(v) != math.Float64bits(v1)) {
+			t.Errorf("parseFloat64(%q) = %v, %v; strconv gives %v, %v", s, v, err, v1, err1)
+		}
+		if math.IsNaN(v1) && !math.IsNaN(v) {
+			t.Errorf("parseFloat64(%q) = %v, expected NaN", s, v)
+		}
+
+		f, err := parseFloat32([]byte(s))
+		f1, err1 := strconv.ParseFloat(s, 32)
+		if (err == nil) != (err1 == nil) || (err == nil && math.Float32bits(f) != math.Float32bits(float32(f1))) {
+			t.Errorf("parseFloat32(%q) = %v, %v; strconv gives %v, %v", s, f, err, f1, err1)
+		}
+	}
+}
+
+func TestParseFloat32s(t *testing.T) {
+	var x, y, z float32
+	if err := ParseToFloat32s([]byte(" 1.5  -2.25 3e2 "), []byte{' '}, &x, &y, &z); err != nil {
+		t.Fatal(err)
+	}
+	if x != 1.5 || y != -2.25 || z != 300 {
+		t.Errorf("Incorrect values %v %v %v", x, y, z)
+	}
+	if err := ParseToFloat32s([]byte("1 2"), []byte{' '}, &x); err == nil {
+		t.Error("An error was expected")
+	}
+
+	var arr []float32
+	if err := ParseToFloat32Arr([]byte("1,2,3"), []byte{','}, &arr, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 3 || arr[2] != 3 {
+		t.Errorf("Incorrect array %v", arr)
+	}
+	if err := ParseToFloat32Arr([]byte("1,2,3,4"), []byte{','}, &arr, false); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+var benchFloats = []byte(" 187.2345671  -12.3456789 0.5734512 1.0347 ")
+
+func BenchmarkParseToFloat64s(b *testing.B) {
+	var x, y, z, w float64
+	b.SetBytes(int64(len(benchFloats)))
+	for i := 0; i < b.N; i++ {
+		ParseToFloat64s(benchFloats, []byte{' '}, &x, &y, &z, &w)
+	}
+}
+
+func BenchmarkParseToFloat32s(b *testing.B) {
+	var x, y, z, w float32
+	b.SetBytes(int64(len(benchFloats)))
+	for i := 0; i < b.N; i++ {
+		ParseToFloat32s(benchFloats, []byte{' '}, &x, &y, &z, &w)
+	}
+}
+
+// BenchmarkSplitStrconv is the previous implementation, for comparison
+func BenchmarkSplitStrconv(b *testing.B) {
+	var args [4]float64
+	b.SetBytes(int64(len(benchFloats)))
+	for i := 0; i < b.N; i++ {
+		j := 0
+		for _, f := range bytes.Split(benchFloats, []byte{' '}) {
+			if len(f) > 0 {
+				args[j], _ = strconv.ParseFloat(unsafeString(f), 64)
+				j++
+			}
+		}
+	}
+}
+
+// Compare against strconv on random short decimals, in both precisions
+func TestParseFloatRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 200000; i++ {
+		s := strconv.FormatFloat((r.Float64()-0.5)*math.Pow(10, float64(r.Intn(40)-20)), 'g', 1+r.Intn(17), 64)
+		v, _ := parseFloat64([]byte(s))
+		v1, _ := strconv.ParseFloat(s, 64)
+		if v != v1 {
+			t.Fatalf("parseFloat64(%q) = %v, strconv gives %v", s, v, v1)
+		}
+		f, _ := parseFloat32([]byte(s))
+		f1, _ := strconv.ParseFloat(s, 32)
+		if f != float32(f1) {
+			t.Fatalf("parseFloat32(%q) = %v, strconv gives %v", s, f, f1)
+		}
+	}
+}
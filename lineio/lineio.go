@@ -4,8 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
-	"os"
-	"strconv"
+	"io"
 	"unsafe"
 )
 
@@ -25,45 +24,106 @@ type LineIOType interface {
 
 // Parameters for line IO -- comment characters
 // The default is "#"
+//
+// Every character in Comment starts a comment, as do the (possibly multi-character)
+// strings in CommentPrefixes. If CommentAtStart is set, comments must start at the
+// beginning of a line (after whitespace), so that eg. a '#' in a field is kept.
+// Comment characters inside quotes (see Dialect.Quote) are ignored.
+//
+// The first SkipLines lines of the input (eg. a header) are skipped.
+//
+// If MaxBadLines > 0, lines that Add fails on are skipped, and parsing continues
+// until more than MaxBadLines lines have failed. The failures are returned as a
+// *ParseErrors.
 type LineIOParams struct {
-	Comment string
+	Comment         string
+	CommentPrefixes []string
+	CommentAtStart  bool
+	SkipLines       int
+	Dialect         Dialect
+	MaxBadLines     int
 }
 
 var (
 	LineIOParamsDefault = LineIOParams{Comment: "#"}
+	LineIOParamsCSV     = LineIOParams{Comment: "#", CommentAtStart: true, Dialect: CSV}
+	LineIOParamsTSV     = LineIOParams{Comment: "#", CommentAtStart: true, Dialect: TSV}
 )
 
 // Parse a file, based on lines. Note that arr may be modified even
 // if an error occured. arr.Add is called with a single line in it, with
 // any comments and leading and trailing whitespace removed.
 // Subsequent parsing is left up to arr.Add
+//
+// Errors returned by Add are wrapped in a *ParseError, which records where
+// the error occured.
+//
+// Compressed files are transparently decompressed, see Open.
 func (l LineIOParams) Parse(fn string, arr LineIOType) error {
 	// Open the file
-	ff, err := os.Open(fn)
+	ff, err := Open(fn)
 	if err != nil {
 		return err
 	}
-	defer ff.Close()
+	err = l.parse(fn, ff, arr)
+	if err1 := ff.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// ParseReader is as Parse, but reads lines from r, eg. os.Stdin, a network stream or
+// a bytes.Buffer.
+func (l LineIOParams) ParseReader(r io.Reader, arr LineIOType) error {
+	return l.parse("", r, arr)
+}
 
-	scan := bufio.NewScanner(ff)
-	scan.Split(bufio.ScanLines)
+// parse does the work for Parse and ParseReader; fn is only used for errors.
+func (l LineIOParams) parse(fn string, r io.Reader, arr LineIOType) error {
+	scan := bufio.NewScanner(r)
+	// Keep track of the byte offset of each line
+	var next int64
+	scan.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		adv, tok, err := bufio.ScanLines(data, atEOF)
+		next += int64(adv)
+		return adv, tok, err
+	})
 	var bb []byte
-	var n int
-	for scan.Scan() {
+	var line int
+	var err error
+	var bad ParseErrors
+	for start := next; scan.Scan(); start = next {
+		line++
+		if line <= l.SkipLines {
+			continue
+		}
 		// Trim out leading and trailing whitespace
 		bb = scan.Bytes()
-		bb = bytes.TrimSpace(bb)
-		if n = bytes.IndexAny(bb, l.Comment); n != -1 {
-			bb = bb[0:n]
-		}
+		bb = l.Dialect.trim(bb)
+		bb = stripComment(bb, l.Comment, l.CommentPrefixes, l.CommentAtStart, l.Dialect.Quote)
+		bb = l.Dialect.trim(bb)
 		if len(bb) > 0 {
 			err = arr.Add(bb)
 			if err != nil {
-				return err
+				perr := newParseError(fn, line, start, scan.Bytes(), err)
+				if l.MaxBadLines <= 0 {
+					return perr
+				}
+				bad.Errors = append(bad.Errors, perr)
+				if len(bad.Errors) > l.MaxBadLines {
+					bad.Aborted = true
+					return &bad
+				}
 			}
 		}
 	}
-	return scan.Err()
+	if err = scan.Err(); err != nil {
+		return err
+	}
+	if len(bad.Errors) > 0 {
+		return &bad
+	}
+	return nil
 }
 
 // Read is equivalent to LineIOParamsDefault.Parse(fn,arr)
@@ -71,29 +131,60 @@ func Read(fn string, arr LineIOType) error {
 	return LineIOParamsDefault.Parse(fn, arr)
 }
 
+// ReadReader is equivalent to LineIOParamsDefault.ParseReader(r,arr)
+func ReadReader(r io.Reader, arr LineIOType) error {
+	return LineIOParamsDefault.ParseReader(r, arr)
+}
+
+var errNumElements = errors.New("Number of elements does not equal number of parameters")
+
+// nextToken returns the first non-empty token in s, splitting on any of the bytes in sep,
+// and the remainder of s. tok is empty if there are no more tokens.
+func nextToken(s, sep []byte) (tok, rest []byte) {
+	if len(sep) == 1 {
+		c := sep[0]
+		i := 0
+		for i < len(s) && s[i] == c {
+			i++
+		}
+		j := i
+		for j < len(s) && s[j] != c {
+			j++
+		}
+		return s[i:j], s[j:]
+	}
+	i := 0
+	for i < len(s) && bytes.IndexByte(sep, s[i]) != -1 {
+		i++
+	}
+	j := i
+	for j < len(s) && bytes.IndexByte(sep, s[j]) == -1 {
+		j++
+	}
+	return s[i:j], s[j:]
+}
+
 // ParseLineToFloat64 parses a line into a sequence of float64s. This throws
-// an error if the number of input arguments does not equal the number of elements parseable
+// an error if the number of input arguments does not equal the number of elements parseable.
+// The elements are separated by any of the bytes in sep, eg. []byte(" ,\t").
 func ParseToFloat64s(s, sep []byte, args ...*float64) error {
-	barr := bytes.Split(s, sep)
 	iarg := 0
 	nargs := len(args)
 	var err error
 	var val float64
-	for i := range barr {
-		if len(barr[i]) == 0 {
-			continue
-		}
-		if val, err = strconv.ParseFloat(unsafeString(barr[i]), 64); err != nil {
+	var tok []byte
+	for tok, s = nextToken(s, sep); len(tok) > 0; tok, s = nextToken(s, sep) {
+		if val, err = parseFloat64(tok); err != nil {
 			return err
 		}
 		if (iarg + 1) > nargs {
-			return errors.New("Number of elements does not equal number of parameters")
+			return errNumElements
 		}
 		*args[iarg] = val
 		iarg++
 	}
 	if iarg != len(args) {
-		return errors.New("Number of elements does not equal number of parameters")
+		return errNumElements
 	}
 	return nil
 }
@@ -101,24 +192,22 @@ func ParseToFloat64s(s, sep []byte, args ...*float64) error {
 // ParseLineToFloat64 parses a line into a sequence of float64s. This throws
 // an error if the number of input arguments does not equal the number of elements parseable.
 // If grow is true, then the slice is automatically appended to.
+// The elements are separated by any of the bytes in sep.
 func ParseToFloat64Arr(s, sep []byte, arr *[]float64, grow bool) error {
-	barr := bytes.Split(s, sep)
 	iarg := 0
 	nargs := len(*arr)
 	var err error
 	var val float64
-	for i := range barr {
-		if len(barr[i]) == 0 {
-			continue
-		}
-		if val, err = strconv.ParseFloat(unsafeString(barr[i]), 64); err != nil {
+	var tok []byte
+	for tok, s = nextToken(s, sep); len(tok) > 0; tok, s = nextToken(s, sep) {
+		if val, err = parseFloat64(tok); err != nil {
 			return err
 		}
 		if (iarg + 1) > nargs {
 			if grow {
 				*arr = append(*arr, val)
 			} else {
-				return errors.New("Number of elements does not equal number of parameters")
+				return errNumElements
 			}
 		} else {
 			(*arr)[iarg] = val
@@ -126,7 +215,7 @@ func ParseToFloat64Arr(s, sep []byte, arr *[]float64, grow bool) error {
 		iarg++
 	}
 	if iarg != len(*arr) {
-		return errors.New("Number of elements does not equal number of parameters")
+		return errNumElements
 	}
 	return nil
 }
@@ -1,6 +1,13 @@
 package lineio
 
 import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/npadmana/npgo/nptest"
@@ -81,3 +88,148 @@ func TestParseFloat64Arr4(t *testing.T) {
 		eps.EqFloat64(truth[i], out[i], "", t)
 	}
 }
+
+// sumLines sums the first column
+type sumLines struct {
+	n   int
+	sum float64
+}
+
+func (s *sumLines) Add(b []byte) error {
+	var x, y float64
+	if err := ParseToFloat64s(b, []byte{' '}, &x, &y); err != nil {
+		return err
+	}
+	s.n++
+	s.sum += x
+	return nil
+}
+
+const sumText = "# x y\n1 2\n  3 4 # comment\n\n5 6\n"
+
+func TestParseReader(t *testing.T) {
+	var s sumLines
+	if err := ReadReader(strings.NewReader(sumText), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.n != 3 {
+		t.Errorf("Expected 3 lines, got %d", s.n)
+	}
+	eps.EqFloat64(9, s.sum, "", t)
+
+	if err := ReadReader(strings.NewReader("1 2 3\n"), &s); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+func TestReadCompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(sumText))
+	w.Close()
+	files := map[string][]byte{"plain.dat": []byte(sumText), "data.dat.gz": gz.Bytes()}
+	for fn, b := range files {
+		if err := os.WriteFile(filepath.Join(dir, fn), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// There is no bzip2 or zstd writer in the standard library, so use the
+	// command line tools if they are available
+	for _, c := range []struct{ cmd, ext string }{{"bzip2", ".bz2"}, {"zstd", ".zst"}} {
+		if _, err := exec.LookPath(c.cmd); err != nil {
+			t.Logf("%s not found, skipping %s", c.cmd, c.ext)
+			continue
+		}
+		fn := filepath.Join(dir, "data.dat")
+		os.WriteFile(fn, []byte(sumText), 0644)
+		if err := exec.Command(c.cmd, "-q", fn).Run(); err != nil {
+			t.Fatal(err)
+		}
+		files["data.dat"+c.ext] = nil
+	}
+
+	for fn := range files {
+		var s sumLines
+		if err := Read(filepath.Join(dir, fn), &s); err != nil {
+			t.Errorf("%s : %v", fn, err)
+			continue
+		}
+		if s.n != 3 {
+			t.Errorf("%s : expected 3 lines, got %d", fn, s.n)
+		}
+		eps.EqFloat64(9, s.sum, fn, t)
+	}
+
+	// Corrupt compressed files
+	for _, fn := range []string{"bad.gz", "bad.zst"} {
+		os.WriteFile(filepath.Join(dir, fn), []byte(sumText), 0644)
+		var s sumLines
+		if err := Read(filepath.Join(dir, fn), &s); err == nil {
+			t.Errorf("%s : an error was expected", fn)
+		}
+	}
+	if err := Read(filepath.Join(dir, "missing.zst"), &sumLines{}); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+// xArr collects the first column
+type xArr []float64
+
+func (a *xArr) Add(b []byte) error {
+	var x, y float64
+	if err := ParseToFloat64s(b, []byte{' '}, &x, &y); err != nil {
+		return err
+	}
+	*a = append(*a, x)
+	return nil
+}
+
+func (a *xArr) Empty() ParallelLineIOType {
+	return new(xArr)
+}
+
+func (a *xArr) Merge(b ParallelLineIOType) error {
+	*a = append(*a, *(b.(*xArr))...)
+	return nil
+}
+
+func TestReadParallel(t *testing.T) {
+	var buf bytes.Buffer
+	n := 1000
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, "%d %d\n", i, i*i)
+		if i%100 == 0 {
+			buf.WriteString("# comment\n\n")
+		}
+	}
+	buf.WriteString(fmt.Sprintf("%d 0", n)) // No trailing newline
+	fn := filepath.Join(t.TempDir(), "data.dat")
+	if err := os.WriteFile(fn, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nchunk := range []int{1, 3, 8, 5000} {
+		var a xArr
+		if err := ReadParallel(fn, nchunk, &a); err != nil {
+			t.Fatal(err)
+		}
+		if len(a) != n+1 {
+			t.Errorf("%d chunks : expected %d lines, got %d", nchunk, n+1, len(a))
+			continue
+		}
+		for i, x := range a {
+			if x != float64(i) {
+				t.Errorf("%d chunks : line %d out of order, got %f", nchunk, i, x)
+				break
+			}
+		}
+	}
+
+	os.WriteFile(fn, []byte("1 2\n3 4 5\n"), 0644)
+	if err := ReadParallel(fn, 2, new(xArr)); err == nil {
+		t.Error("An error was expected")
+	}
+}
@@ -0,0 +1,183 @@
+package lineio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ParallelLineIOType is a LineIOType that can be parsed in independent chunks, which
+// are then merged together.
+type ParallelLineIOType interface {
+	LineIOType
+	// Empty returns a new, empty value to parse a chunk into
+	Empty() ParallelLineIOType
+	// Merge appends a parsed chunk (returned by Empty) onto the receiver
+	Merge(ParallelLineIOType) error
+}
+
+// ParseParallel is as Parse, but splits the file into nchunk byte ranges aligned on
+// newlines, and parses these on separate goroutines. The chunks are merged into arr in
+// file order.
+//
+// Compressed files (see Open) cannot be split, and are parsed serially.
+func (l LineIOParams) ParseParallel(fn string, nchunk int, arr ParallelLineIOType) error {
+	switch filepath.Ext(fn) {
+	case ".gz", ".bz2", ".zst":
+		return l.Parse(fn, arr)
+	}
+	if nchunk < 1 {
+		return errors.New("Number of chunks must be positive")
+	}
+
+	ff, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer ff.Close()
+	fi, err := ff.Stat()
+	if err != nil {
+		return err
+	}
+	// Skip the header lines here, rather than in the first chunk
+	start, err := skipLines(ff, fi.Size(), l.SkipLines)
+	if err != nil {
+		return err
+	}
+	bounds, err := chunkBounds(ff, start, fi.Size(), nchunk)
+	if err != nil {
+		return err
+	}
+	l.SkipLines = 0
+
+	nchunk = len(bounds) - 1
+	chunks := make([]ParallelLineIOType, nchunk)
+	errs := make([]error, nchunk)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		chunks[i] = arr.Empty()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := io.NewSectionReader(ff, bounds[i], bounds[i+1]-bounds[i])
+			errs[i] = l.parse(fn, r, chunks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var bad ParseErrors
+	for i := range chunks {
+		if err = arr.Merge(chunks[i]); err != nil {
+			return err
+		}
+		if errs[i] == nil {
+			continue
+		}
+		// Errors are relative to the start of the chunk
+		nline, err := countLines(ff, bounds[i])
+		if err != nil {
+			return err
+		}
+		switch e := errs[i].(type) {
+		case *ParseError:
+			e.Line += nline
+			e.Offset += bounds[i]
+			return e
+		case *ParseErrors:
+			for _, e1 := range e.Errors {
+				e1.Line += nline
+				e1.Offset += bounds[i]
+				bad.Errors = append(bad.Errors, e1)
+			}
+			if e.Aborted || len(bad.Errors) > l.MaxBadLines {
+				bad.Errors = bad.Errors[:l.MaxBadLines+1]
+				bad.Aborted = true
+				return &bad
+			}
+		default:
+			return e
+		}
+	}
+	if len(bad.Errors) > 0 {
+		return &bad
+	}
+	return nil
+}
+
+// nextLine returns the offset just after the next newline at or after off, or size
+func nextLine(r io.ReaderAt, off, size int64) (int64, error) {
+	buf := make([]byte, 4096)
+	for off < size {
+		n, err := r.ReadAt(buf, off)
+		if n == 0 && err != nil {
+			return 0, err
+		}
+		if j := bytes.IndexByte(buf[:n], '\n'); j != -1 {
+			return off + int64(j) + 1, nil
+		}
+		off += int64(n)
+	}
+	return size, nil
+}
+
+// skipLines returns the offset of the start of line n+1
+func skipLines(r io.ReaderAt, size int64, n int) (int64, error) {
+	var off int64
+	var err error
+	for i := 0; i < n && off < size; i++ {
+		if off, err = nextLine(r, off, size); err != nil {
+			return 0, err
+		}
+	}
+	return off, nil
+}
+
+// countLines counts the newlines in the first n bytes of r
+func countLines(r io.ReaderAt, n int64) (int, error) {
+	buf := make([]byte, 1<<16)
+	count := 0
+	for off := int64(0); off < n; {
+		m := int64(len(buf))
+		if n-off < m {
+			m = n - off
+		}
+		k, err := r.ReadAt(buf[:m], off)
+		count += bytes.Count(buf[:k], []byte{'\n'})
+		off += int64(k)
+		if err != nil && off < n {
+			return 0, err
+		}
+	}
+	return count, nil
+}
+
+// ReadParallel is equivalent to LineIOParamsDefault.ParseParallel(fn,nchunk,arr)
+func ReadParallel(fn string, nchunk int, arr ParallelLineIOType) error {
+	return LineIOParamsDefault.ParseParallel(fn, nchunk, arr)
+}
+
+// chunkBounds splits [start, size) into at most nchunk ranges. Every range but the last
+// ends just after a newline. Returns the nchunk+1 boundaries; empty ranges are dropped.
+func chunkBounds(r io.ReaderAt, start, size int64, nchunk int) ([]int64, error) {
+	bounds := []int64{start}
+	for i := 1; i < nchunk; i++ {
+		off := start + (size-start)*int64(i)/int64(nchunk)
+		if prev := bounds[len(bounds)-1]; off < prev {
+			off = prev
+		}
+		off, err := nextLine(r, off, size)
+		if err != nil {
+			return nil, err
+		}
+		if off >= size {
+			break
+		}
+		if off > bounds[len(bounds)-1] {
+			bounds = append(bounds, off)
+		}
+	}
+	return append(bounds, size), nil
+}
@@ -0,0 +1,278 @@
+package lineio
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ColumnType is the type of an output column
+type ColumnType int
+
+const (
+	FloatColumn ColumnType = iota
+	IntColumn
+	StringColumn
+)
+
+// Column describes an output column. Values are right-aligned in at least Width
+// characters, and columns are separated by a space.
+//
+// Floats are formatted with strconv.AppendFloat, using Format ('f', 'e' or 'g'; the
+// default is 'f') and Precision. As with strconv, a Precision of -1 uses the fewest
+// digits that represent the value exactly.
+type Column struct {
+	Name      string
+	Type      ColumnType
+	Width     int
+	Precision int
+	Format    byte
+}
+
+// Writer writes rows of typed columns. Output is buffered; the first error is kept, and
+// returned by EndRow, Flush and Close.
+//
+// Rows are written a value at a time :
+//
+//	w.Float(x)
+//	w.Float(y)
+//	w.Int(id)
+//	err := w.EndRow()
+type Writer struct {
+	cols []Column
+	w    *bufio.Writer
+	buf  []byte
+	icol int
+	err  error
+
+	// For atomic writes
+	f         *os.File
+	fn, tmpfn string
+	closed    bool
+}
+
+// NewWriter returns a Writer writing to w
+func NewWriter(w io.Writer, cols []Column) *Writer {
+	cw := new(Writer)
+	cw.cols = append([]Column(nil), cols...)
+	for i := range cw.cols {
+		if cw.cols[i].Format == 0 {
+			cw.cols[i].Format = 'f'
+		}
+	}
+	cw.w = bufio.NewWriterSize(w, 1<<16)
+	return cw
+}
+
+// Create returns a Writer for the file fn. The output is written to a temporary file in
+// the same directory, which is renamed to fn by Close. If Close is not called, or there
+// was an error, fn is left untouched.
+func Create(fn string, cols []Column) (*Writer, error) {
+	f, err := os.CreateTemp(filepath.Dir(fn), "."+filepath.Base(fn)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	// CreateTemp makes the file private
+	if err = f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	w := NewWriter(f, cols)
+	w.f, w.fn, w.tmpfn = f, fn, f.Name()
+	return w, nil
+}
+
+// Comment writes a comment line, starting with "# "
+func (w *Writer) Comment(format string, args ...interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.icol != 0 {
+		w.err = errors.New("Comment in the middle of a row")
+		return w.err
+	}
+	_, w.err = fmt.Fprintf(w.w, "# "+format+"\n", args...)
+	return w.err
+}
+
+// Meta writes a metadata comment line, "# key = value"
+func (w *Writer) Meta(key string, value interface{}) error {
+	return w.Comment("%s = %v", key, value)
+}
+
+// Header writes a comment line with the column names, aligned with the columns
+func (w *Writer) Header() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.icol != 0 {
+		w.err = errors.New("Header in the middle of a row")
+		return w.err
+	}
+	w.buf = append(w.buf[:0], '#')
+	for i, c := range w.cols {
+		width := c.Width
+		if i == 0 {
+			width -= 2 // Room for the "# "
+		}
+		w.buf = pad(append(w.buf, ' '), c.Name, width)
+	}
+	w.buf = append(w.buf, '\n')
+	_, w.err = w.w.Write(w.buf)
+	return w.err
+}
+
+// pad appends s to buf, right aligned in width
+func pad(buf []byte, s string, width int) []byte {
+	for i := len(s); i < width; i++ {
+		buf = append(buf, ' ')
+	}
+	return append(buf, s...)
+}
+
+// next returns the next column, checking its type
+func (w *Writer) next(t ColumnType) (Column, bool) {
+	if w.err != nil {
+		return Column{}, false
+	}
+	if w.icol >= len(w.cols) {
+		w.err = fmt.Errorf("Too many values in row, expected %d", len(w.cols))
+		return Column{}, false
+	}
+	c := w.cols[w.icol]
+	if c.Type != t {
+		w.err = fmt.Errorf("Incorrect type for column %d (%s)", w.icol, c.Name)
+		return Column{}, false
+	}
+	if w.icol == 0 {
+		w.buf = w.buf[:0]
+	} else {
+		w.buf = append(w.buf, ' ')
+	}
+	w.icol++
+	return c, true
+}
+
+// align right-aligns the value appended to w.buf from start
+func (w *Writer) align(start, width int) {
+	n := len(w.buf) - start
+	if n >= width {
+		return
+	}
+	for i := n; i < width; i++ {
+		w.buf = append(w.buf, ' ')
+	}
+	copy(w.buf[start+width-n:], w.buf[start:start+n])
+	for i := start; i < start+width-n; i++ {
+		w.buf[i] = ' '
+	}
+}
+
+// Float writes a float to the next column
+func (w *Writer) Float(x float64) {
+	c, ok := w.next(FloatColumn)
+	if !ok {
+		return
+	}
+	start := len(w.buf)
+	w.buf = strconv.AppendFloat(w.buf, x, c.Format, c.Precision, 64)
+	w.align(start, c.Width)
+}
+
+// Int writes an integer to the next column
+func (w *Writer) Int(i int64) {
+	c, ok := w.next(IntColumn)
+	if !ok {
+		return
+	}
+	start := len(w.buf)
+	w.buf = strconv.AppendInt(w.buf, i, 10)
+	w.align(start, c.Width)
+}
+
+// String writes a string to the next column
+func (w *Writer) String(s string) {
+	c, ok := w.next(StringColumn)
+	if !ok {
+		return
+	}
+	w.buf = pad(w.buf, s, c.Width)
+}
+
+// EndRow finishes the current row
+func (w *Writer) EndRow() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.icol != len(w.cols) {
+		w.err = fmt.Errorf("Too few values in row, expected %d, got %d", len(w.cols), w.icol)
+		return w.err
+	}
+	w.icol = 0
+	w.buf = append(w.buf, '\n')
+	_, w.err = w.w.Write(w.buf)
+	return w.err
+}
+
+// WriteFloats writes a row of floats; all the columns must be FloatColumns
+func (w *Writer) WriteFloats(x ...float64) error {
+	for _, x1 := range x {
+		w.Float(x1)
+	}
+	return w.EndRow()
+}
+
+// Flush writes out any buffered data
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// Close flushes the output. For a Writer from Create, it syncs and closes the temporary
+// file, and renames it to the final name; on any error, the temporary file is removed.
+// Close does not close the io.Writer passed to NewWriter.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err == nil && w.icol != 0 {
+		w.err = errors.New("Close in the middle of a row")
+	}
+	w.Flush()
+	if w.f == nil {
+		return w.err
+	}
+	if w.err == nil {
+		w.err = w.f.Sync()
+	}
+	if err := w.f.Close(); w.err == nil {
+		w.err = err
+	}
+	if w.err == nil {
+		w.err = os.Rename(w.tmpfn, w.fn)
+	}
+	if w.err != nil {
+		os.Remove(w.tmpfn)
+	}
+	return w.err
+}
+
+// Abort discards the output of a Writer from Create, leaving the final file untouched.
+func (w *Writer) Abort() {
+	if w.closed || w.f == nil {
+		return
+	}
+	w.closed = true
+	w.err = errors.New("Writer aborted")
+	w.f.Close()
+	os.Remove(w.tmpfn)
+}
@@ -0,0 +1,119 @@
+package lineio
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testCols = []Column{
+	{Name: "x", Width: 10, Precision: 4},
+	{Name: "w", Width: 7, Precision: 4},
+	{Name: "id", Type: IntColumn, Width: 8},
+	{Name: "name", Type: StringColumn, Width: 5},
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, testCols)
+	w.Meta("zmin", 0.43)
+	w.Header()
+	for i, x := range []float64{-1.23456, 123456.5} {
+		w.Float(x)
+		w.Float(0.5)
+		w.Int(int64(i + 1))
+		w.String("ab")
+		if err := w.EndRow(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare against fmt
+	var expected bytes.Buffer
+	fmt.Fprintf(&expected, "# zmin = 0.43\n#        x       w       id  name\n")
+	fmt.Fprintf(&expected, "%10.4f %7.4f %8d %5s\n", -1.23456, 0.5, 1, "ab")
+	fmt.Fprintf(&expected, "%10.4f %7.4f %8d %5s\n", 123456.5, 0.5, 2, "ab")
+	if buf.String() != expected.String() {
+		t.Errorf("Expected\n%s\ngot\n%s", expected.String(), buf.String())
+	}
+
+	// Type and count errors
+	w = NewWriter(&buf, testCols)
+	w.Int(1)
+	if err := w.EndRow(); err == nil {
+		t.Error("An error was expected")
+	}
+	w = NewWriter(&buf, testCols[:1])
+	if err := w.WriteFloats(1, 2); err == nil {
+		t.Error("An error was expected")
+	}
+}
+
+func TestWriterAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "out.dat")
+	cols := []Column{{Name: "x", Width: 6, Precision: 2, Format: 'e'}}
+
+	w, err := Create(fn, cols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteFloats(1234.5)
+	if _, err = os.Stat(fn); !os.IsNotExist(err) {
+		t.Error("File exists before Close")
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(fn)
+	if string(b) != "1.23e+03\n" {
+		t.Errorf("Incorrect output %q", b)
+	}
+
+	// An aborted (or failed) write leaves the old file
+	w, _ = Create(fn, cols)
+	w.WriteFloats(1)
+	w.Abort()
+	w, _ = Create(fn, cols)
+	w.Float(1)
+	if err = w.Close(); err == nil {
+		t.Error("An error was expected closing mid-row")
+	}
+	b, _ = os.ReadFile(fn)
+	if string(b) != "1.23e+03\n" {
+		t.Errorf("Output was overwritten : %q", b)
+	}
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("Temporary files left behind : %v", files)
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, testCols[:3])
+	for i := 0; i < b.N; i++ {
+		w.Float(1234.5678)
+		w.Float(0.1234)
+		w.Int(int64(i))
+		w.EndRow()
+		if buf.Len() > 1<<20 {
+			buf.Reset()
+		}
+	}
+}
+
+func BenchmarkFprintf(b *testing.B) {
+	var buf bytes.Buffer
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(&buf, "%10.4f %7.4f %8d\n", 1234.5678, 0.1234, i)
+		if buf.Len() > 1<<20 {
+			buf.Reset()
+		}
+	}
+}
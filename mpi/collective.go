@@ -0,0 +1,390 @@
+package mpi
+
+/*
+#cgo pkg-config: ompi mpich
+
+#include "mpi.h"
+
+// The reductions are done in place if in == out.
+static void *inplace(const void *in, void *out) {
+	return (in == out) ? MPI_IN_PLACE : (void *) in;
+}
+
+static int allreduce(void *in, void *out, int n, MPI_Datatype t, MPI_Op op, MPI_Comm comm) {
+	return MPI_Allreduce(inplace(in, out), out, n, t, op, comm);
+}
+
+static int reduce(void *in, void *out, int n, MPI_Datatype t, MPI_Op op, int root, MPI_Comm comm) {
+	int rank;
+	MPI_Comm_rank(comm, &rank);
+	if (rank == root) {
+		in = inplace(in, out);
+	}
+	return MPI_Reduce(in, out, n, t, op, root, comm);
+}
+
+static int scan(void *in, void *out, int n, MPI_Datatype t, MPI_Op op, MPI_Comm comm) {
+	return MPI_Scan(inplace(in, out), out, n, t, op, comm);
+}
+
+static int exscan(void *in, void *out, int n, MPI_Datatype t, MPI_Op op, MPI_Comm comm) {
+	return MPI_Exscan(inplace(in, out), out, n, t, op, comm);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Numeric are the element types supported by the collectives
+type Numeric interface {
+	~float64 | ~float32 | ~int32 | ~int64 | ~byte
+}
+
+// Datatype returns the MPI datatype for T. Bytes are MPI_UNSIGNED_CHAR, not MPI_BYTE, so
+// that they can be reduced.
+func Datatype[T Numeric]() MpiType {
+	var x T
+	switch reflect.TypeOf(x).Kind() {
+	case reflect.Float64:
+		return MPI_f64
+	case reflect.Float32:
+		return MPI_f32
+	case reflect.Int32:
+		return MPI_i32
+	case reflect.Int64:
+		return MPI_i64
+	}
+	return MPI_u8
+}
+
+// isFloat returns true if T is a floating point type
+func isFloat[T Numeric]() bool {
+	var x T
+	k := reflect.TypeOf(x).Kind()
+	return k == reflect.Float64 || k == reflect.Float32
+}
+
+// checkOp checks that op can be applied to T; MPI only defines LAND and LOR for integers.
+func checkOp[T Numeric](op Op) error {
+	if (op == LAND || op == LOR) && isFloat[T]() {
+		var x T
+		return fmt.Errorf("Logical reductions are not defined for %T", x)
+	}
+	return nil
+}
+
+// ptr returns a pointer to the start of x, or nil if it is empty
+func ptr[T Numeric](x []T) unsafe.Pointer {
+	if len(x) == 0 {
+		return nil
+	}
+	return unsafe.Pointer(&x[0])
+}
+
+// cints converts counts to C ints
+func cints(x []int) ([]C.int, error) {
+	out := make([]C.int, len(x))
+	for i, x1 := range x {
+		if x1 < 0 || x1 > math.MaxInt32 {
+			return nil, fmt.Errorf("Count %d out of range", x1)
+		}
+		out[i] = C.int(x1)
+	}
+	return out, nil
+}
+
+// displacements returns the offsets of each block of counts, and the total
+func displacements(counts []C.int) ([]C.int, int, error) {
+	displs := make([]C.int, len(counts))
+	var total int
+	for i, n := range counts {
+		if total > math.MaxInt32 {
+			return nil, 0, fmt.Errorf("Total count %d out of range", total)
+		}
+		displs[i] = C.int(total)
+		total += int(n)
+	}
+	return displs, total, nil
+}
+
+// intPtr returns a pointer to the start of x, or nil if it is empty
+func intPtr(x []C.int) *C.int {
+	if len(x) == 0 {
+		return nil
+	}
+	return &x[0]
+}
+
+// rankSize returns the rank and size of comm
+func rankSize(comm Comm) (int, int, error) {
+	rank, err := Rank(comm)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err := Size(comm)
+	if err != nil {
+		return 0, 0, err
+	}
+	return rank, size, nil
+}
+
+func mpiErr(perr C.int, name string) error {
+	if perr != 0 {
+		return errors.New("Error calling MPI_" + name)
+	}
+	return nil
+}
+
+// Allreduce combines in from all ranks with op, leaving the result in out on every rank.
+// in and out may be the same slice.
+func Allreduce[T Numeric](comm Comm, in, out []T, op Op) error {
+	if len(in) != len(out) {
+		return fmt.Errorf("Incompatible dimensions in Allreduce: len(in)=%d, len(out)=%d", len(in), len(out))
+	}
+	if err := checkOp[T](op); err != nil {
+		return err
+	}
+	t := Datatype[T]()
+	return mpiErr(C.allreduce(ptr(in), ptr(out), C.int(len(in)), C.MPI_Datatype(t), C.MPI_Op(op), C.MPI_Comm(comm)), "Allreduce")
+}
+
+// Reduce combines in from all ranks with op, leaving the result in out on root. out is
+// ignored on the other ranks, and may be nil. in and out may be the same slice.
+func Reduce[T Numeric](comm Comm, in, out []T, op Op, root int) error {
+	rank, err := Rank(comm)
+	if err != nil {
+		return err
+	}
+	if rank == root && len(in) != len(out) {
+		return fmt.Errorf("Incompatible dimensions in Reduce: len(in)=%d, len(out)=%d", len(in), len(out))
+	}
+	if err = checkOp[T](op); err != nil {
+		return err
+	}
+	t := Datatype[T]()
+	return mpiErr(C.reduce(ptr(in), ptr(out), C.int(len(in)), C.MPI_Datatype(t), C.MPI_Op(op), C.int(root), C.MPI_Comm(comm)), "Reduce")
+}
+
+// Bcast copies buf on root to buf on all the other ranks, which must have the same length.
+func Bcast[T Numeric](comm Comm, buf []T, root int) error {
+	t := Datatype[T]()
+	return mpiErr(C.MPI_Bcast(ptr(buf), C.int(len(buf)), C.MPI_Datatype(t), C.int(root), C.MPI_Comm(comm)), "Bcast")
+}
+
+// Allgather collects in from all ranks, in rank order, into out on every rank.
+// in must have the same length on all ranks, and out size times that.
+func Allgather[T Numeric](comm Comm, in, out []T) error {
+	_, size, err := rankSize(comm)
+	if err != nil {
+		return err
+	}
+	if len(out) != size*len(in) {
+		return fmt.Errorf("Incompatible dimensions in Allgather: len(in)=%d, len(out)=%d, %d ranks", len(in), len(out), size)
+	}
+	t := C.MPI_Datatype(Datatype[T]())
+	return mpiErr(C.MPI_Allgather(ptr(in), C.int(len(in)), t, ptr(out), C.int(len(in)), t, C.MPI_Comm(comm)), "Allgather")
+}
+
+// Gather collects in from all ranks, in rank order, into out on root. in must have the
+// same length on all ranks, and out size times that; out is ignored on the other ranks.
+func Gather[T Numeric](comm Comm, in, out []T, root int) error {
+	rank, size, err := rankSize(comm)
+	if err != nil {
+		return err
+	}
+	if rank == root && len(out) != size*len(in) {
+		return fmt.Errorf("Incompatible dimensions in Gather: len(in)=%d, len(out)=%d, %d ranks", len(in), len(out), size)
+	}
+	t := C.MPI_Datatype(Datatype[T]())
+	return mpiErr(C.MPI_Gather(ptr(in), C.int(len(in)), t, ptr(out), C.int(len(in)), t, C.int(root), C.MPI_Comm(comm)), "Gather")
+}
+
+// Gatherv collects in from all ranks, in rank order, on root. Unlike Gather, in may have a
+// different length on each rank. On root, it returns the data and the number of elements
+// from each rank; on the other ranks, both are nil.
+func Gatherv[T Numeric](comm Comm, in []T, root int) ([]T, []int, error) {
+	rank, size, err := rankSize(comm)
+	if err != nil {
+		return nil, nil, err
+	}
+	n := C.int(len(in))
+	var counts []C.int
+	if rank == root {
+		counts = make([]C.int, size)
+	}
+	ti := C.MPI_Datatype(MPI_i32)
+	perr := C.MPI_Gather(unsafe.Pointer(&n), 1, ti, unsafe.Pointer(intPtr(counts)), 1, ti, C.int(root), C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Gather"); err != nil {
+		return nil, nil, err
+	}
+
+	var out []T
+	var displs []C.int
+	var icounts []int
+	if rank == root {
+		var total int
+		if displs, total, err = displacements(counts); err != nil {
+			return nil, nil, err
+		}
+		out = make([]T, total)
+		icounts = make([]int, size)
+		for i, c := range counts {
+			icounts[i] = int(c)
+		}
+	}
+	t := C.MPI_Datatype(Datatype[T]())
+	perr = C.MPI_Gatherv(ptr(in), n, t, ptr(out), intPtr(counts), intPtr(displs), t, C.int(root), C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Gatherv"); err != nil {
+		return nil, nil, err
+	}
+	return out, icounts, nil
+}
+
+// Scatter sends consecutive blocks of len(out) elements of in on root to each rank in
+// turn, storing them in out. in is ignored on the other ranks.
+func Scatter[T Numeric](comm Comm, in, out []T, root int) error {
+	rank, size, err := rankSize(comm)
+	if err != nil {
+		return err
+	}
+	if rank == root && len(in) != size*len(out) {
+		return fmt.Errorf("Incompatible dimensions in Scatter: len(in)=%d, len(out)=%d, %d ranks", len(in), len(out), size)
+	}
+	t := C.MPI_Datatype(Datatype[T]())
+	return mpiErr(C.MPI_Scatter(ptr(in), C.int(len(out)), t, ptr(out), C.int(len(out)), t, C.int(root), C.MPI_Comm(comm)), "Scatter")
+}
+
+// Scatterv sends consecutive blocks of in on root, of counts[i] elements, to each rank i,
+// and returns the block received. in and counts are ignored on the other ranks.
+func Scatterv[T Numeric](comm Comm, in []T, counts []int, root int) ([]T, error) {
+	rank, size, err := rankSize(comm)
+	if err != nil {
+		return nil, err
+	}
+	var ccounts, displs []C.int
+	if rank == root {
+		if len(counts) != size {
+			return nil, fmt.Errorf("Incompatible dimensions in Scatterv: len(counts)=%d, %d ranks", len(counts), size)
+		}
+		if ccounts, err = cints(counts); err != nil {
+			return nil, err
+		}
+		var total int
+		if displs, total, err = displacements(ccounts); err != nil {
+			return nil, err
+		}
+		if total != len(in) {
+			return nil, fmt.Errorf("Incompatible dimensions in Scatterv: len(in)=%d, sum(counts)=%d", len(in), total)
+		}
+	}
+
+	var n C.int
+	ti := C.MPI_Datatype(MPI_i32)
+	perr := C.MPI_Scatter(unsafe.Pointer(intPtr(ccounts)), 1, ti, unsafe.Pointer(&n), 1, ti, C.int(root), C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Scatter"); err != nil {
+		return nil, err
+	}
+	out := make([]T, int(n))
+	t := C.MPI_Datatype(Datatype[T]())
+	perr = C.MPI_Scatterv(ptr(in), intPtr(ccounts), intPtr(displs), t, ptr(out), n, t, C.int(root), C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Scatterv"); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Alltoall sends consecutive blocks of in to each rank in turn, and receives the blocks
+// sent to this rank into out, in rank order. in and out must have the same length, a
+// multiple of the number of ranks.
+func Alltoall[T Numeric](comm Comm, in, out []T) error {
+	_, size, err := rankSize(comm)
+	if err != nil {
+		return err
+	}
+	if len(in) != len(out) || len(in)%size != 0 {
+		return fmt.Errorf("Incompatible dimensions in Alltoall: len(in)=%d, len(out)=%d, %d ranks", len(in), len(out), size)
+	}
+	n := C.int(len(in) / size)
+	t := C.MPI_Datatype(Datatype[T]())
+	return mpiErr(C.MPI_Alltoall(ptr(in), n, t, ptr(out), n, t, C.MPI_Comm(comm)), "Alltoall")
+}
+
+// Alltoallv sends consecutive blocks of in, of counts[i] elements, to each rank i. It
+// returns the blocks received from each rank in rank order, and their lengths.
+func Alltoallv[T Numeric](comm Comm, in []T, counts []int) ([]T, []int, error) {
+	_, size, err := rankSize(comm)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(counts) != size {
+		return nil, nil, fmt.Errorf("Incompatible dimensions in Alltoallv: len(counts)=%d, %d ranks", len(counts), size)
+	}
+	scounts, err := cints(counts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sdispls, total, err := displacements(scounts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if total != len(in) {
+		return nil, nil, fmt.Errorf("Incompatible dimensions in Alltoallv: len(in)=%d, sum(counts)=%d", len(in), total)
+	}
+
+	// Exchange the counts
+	rcounts := make([]C.int, size)
+	ti := C.MPI_Datatype(MPI_i32)
+	perr := C.MPI_Alltoall(unsafe.Pointer(&scounts[0]), 1, ti, unsafe.Pointer(&rcounts[0]), 1, ti, C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Alltoall"); err != nil {
+		return nil, nil, err
+	}
+	rdispls, total, err := displacements(rcounts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out := make([]T, total)
+	t := C.MPI_Datatype(Datatype[T]())
+	perr = C.MPI_Alltoallv(ptr(in), &scounts[0], &sdispls[0], t, ptr(out), &rcounts[0], &rdispls[0], t, C.MPI_Comm(comm))
+	if err = mpiErr(perr, "Alltoallv"); err != nil {
+		return nil, nil, err
+	}
+	icounts := make([]int, size)
+	for i, c := range rcounts {
+		icounts[i] = int(c)
+	}
+	return out, icounts, nil
+}
+
+// Scan computes the inclusive prefix reduction of in with op over the ranks, ie. out on
+// rank i combines in from ranks 0..i. in and out may be the same slice.
+func Scan[T Numeric](comm Comm, in, out []T, op Op) error {
+	if len(in) != len(out) {
+		return fmt.Errorf("Incompatible dimensions in Scan: len(in)=%d, len(out)=%d", len(in), len(out))
+	}
+	if err := checkOp[T](op); err != nil {
+		return err
+	}
+	t := Datatype[T]()
+	return mpiErr(C.scan(ptr(in), ptr(out), C.int(len(in)), C.MPI_Datatype(t), C.MPI_Op(op), C.MPI_Comm(comm)), "Scan")
+}
+
+// Exscan computes the exclusive prefix reduction of in with op over the ranks, ie. out on
+// rank i combines in from ranks 0..i-1. out is undefined on rank 0. in and out may
+// be the same slice.
+func Exscan[T Numeric](comm Comm, in, out []T, op Op) error {
+	if len(in) != len(out) {
+		return fmt.Errorf("Incompatible dimensions in Exscan: len(in)=%d, len(out)=%d", len(in), len(out))
+	}
+	if err := checkOp[T](op); err != nil {
+		return err
+	}
+	t := Datatype[T]()
+	return mpiErr(C.exscan(ptr(in), ptr(out), C.int(len(in)), C.MPI_Datatype(t), C.MPI_Op(op), C.MPI_Comm(comm)), "Exscan")
+}
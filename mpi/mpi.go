@@ -15,6 +15,21 @@ MPI_Op mpiop(int i) {
         case 0 :
                 retval = MPI_SUM;
                 break;
+        case 1 :
+                retval = MPI_MIN;
+                break;
+        case 2 :
+                retval = MPI_MAX;
+                break;
+        case 3 :
+                retval = MPI_PROD;
+                break;
+        case 4 :
+                retval = MPI_LAND;
+                break;
+        case 5 :
+                retval = MPI_LOR;
+                break;
         default :
                 MPI_Abort(MPI_COMM_WORLD,1);
         }
@@ -28,6 +43,18 @@ MPI_Datatype mpitype(int i) {
         case 0 :
                 retval = MPI_LONG;
                 break;
+        case 1 :
+                retval = MPI_DOUBLE;
+                break;
+        case 2 :
+                retval = MPI_INT;
+                break;
+        case 3 :
+                retval = MPI_FLOAT;
+                break;
+        case 4 :
+                retval = MPI_UNSIGNED_CHAR;
+                break;
         default :
                 MPI_Abort(MPI_COMM_WORLD,1);
         }
@@ -54,11 +81,20 @@ type Op C.MPI_Op            // MPI Optypes
 type MpiType C.MPI_Datatype // MPI Datatypes
 
 var (
-	SUM = Op(C.mpiop(0)) // MPI_SUM
+	SUM  = Op(C.mpiop(0)) // MPI_SUM
+	MIN  = Op(C.mpiop(1)) // MPI_MIN
+	MAX  = Op(C.mpiop(2)) // MPI_MAX
+	PROD = Op(C.mpiop(3)) // MPI_PROD
+	LAND = Op(C.mpiop(4)) // MPI_LAND
+	LOR  = Op(C.mpiop(5)) // MPI_LOR
 )
 
 var (
 	MPI_i64 = MpiType(C.mpitype(0))
+	MPI_f64 = MpiType(C.mpitype(1))
+	MPI_i32 = MpiType(C.mpitype(2))
+	MPI_f32 = MpiType(C.mpitype(3))
+	MPI_u8  = MpiType(C.mpitype(4)) // MPI_UNSIGNED_CHAR, since MPI_BYTE has no reductions
 	WORLD   = Comm(C.retworld())
 )
 
@@ -99,7 +135,7 @@ func Finalize() error {
 
 // AllReduceInt64 : MPI_Allreduce for int64
 func AllReduceInt64(comm Comm, in, out *int64, n int, op Op) {
-	C.MPI_Allreduce(unsafe.Pointer(in), unsafe.Pointer(out), C.int(n), C.MPI_Datatype(MPI_i64), C.MPI_Op(SUM), C.MPI_Comm(comm))
+	C.MPI_Allreduce(unsafe.Pointer(in), unsafe.Pointer(out), C.int(n), C.MPI_Datatype(MPI_i64), C.MPI_Op(op), C.MPI_Comm(comm))
 }
 
 // AllGatherInt64 : MPI_Allgather for int64
@@ -151,3 +187,21 @@ func TypeSize(t1 MpiType) int {
 	C.MPI_Type_size(C.MPI_Datatype(t1), &n)
 	return int(n)
 }
+
+// Gatherer gathers data onto rank 0 of Comm, eg. for the gnuplot plotting helpers
+type Gatherer struct {
+	Comm Comm
+}
+
+// Rank returns the rank in Comm
+func (g Gatherer) Rank() int {
+	r, _ := Rank(g.Comm)
+	return r
+}
+
+// GatherFloat64 gathers x from all ranks onto rank 0, concatenated in rank order.
+// The other ranks get nil.
+func (g Gatherer) GatherFloat64(x []float64) ([]float64, error) {
+	out, _, err := Gatherv(g.Comm, x, 0)
+	return out, err
+}
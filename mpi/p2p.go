@@ -0,0 +1,256 @@
+package mpi
+
+/*
+#cgo pkg-config: ompi mpich
+
+#include "mpi.h"
+
+static MPI_Request reqnull(void) {
+	return MPI_REQUEST_NULL;
+}
+
+static int isnull(MPI_Request r) {
+	return r == MPI_REQUEST_NULL;
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+)
+
+var (
+	AnySource = int(C.MPI_ANY_SOURCE) // Receive from any rank
+	AnyTag    = int(C.MPI_ANY_TAG)    // Receive with any tag
+)
+
+// Status describes a received (or probed) message
+type Status struct {
+	Source int // Sending rank
+	Tag    int
+	Count  int // Number of elements
+}
+
+// status converts st, counting elements of type t
+func status(st *C.MPI_Status, t C.MPI_Datatype) (Status, error) {
+	var n C.int
+	if err := mpiErr(C.MPI_Get_count(st, t, &n), "Get_count"); err != nil {
+		return Status{}, err
+	}
+	if n == C.MPI_UNDEFINED {
+		return Status{}, fmt.Errorf("Message from rank %d is not a whole number of elements", int(st.MPI_SOURCE))
+	}
+	return Status{Source: int(st.MPI_SOURCE), Tag: int(st.MPI_TAG), Count: int(n)}, nil
+}
+
+// Request is a pending non-blocking send or receive, from Isend or Irecv. The buffer
+// must not be used until the request completes.
+type Request struct {
+	req    C.MPI_Request
+	t      C.MPI_Datatype
+	n      int // Elements sent, for sends
+	recv   bool
+	pin    runtime.Pinner // Keeps the buffer in place while MPI holds it
+	done   bool
+	status Status
+}
+
+// newRequest pins buf, which MPI holds on to after the call returns
+func newRequest[T Numeric](buf []T, recv bool) *Request {
+	r := &Request{req: C.reqnull(), t: C.MPI_Datatype(Datatype[T]()), n: len(buf), recv: recv}
+	if len(buf) > 0 {
+		r.pin.Pin(&buf[0])
+	}
+	return r
+}
+
+// complete records the status of a finished request
+func (r *Request) complete(st *C.MPI_Status) (Status, error) {
+	r.done = true
+	r.pin.Unpin()
+	if !r.recv {
+		r.status = Status{Source: int(st.MPI_SOURCE), Tag: int(st.MPI_TAG), Count: r.n}
+		return r.status, nil
+	}
+	var err error
+	r.status, err = status(st, r.t)
+	return r.status, err
+}
+
+// Wait waits for the request to complete. For receives, the Status describes the message
+// received; for sends, only Count (the number of elements sent) is meaningful.
+func (r *Request) Wait() (Status, error) {
+	if r.done {
+		return r.status, nil
+	}
+	var st C.MPI_Status
+	if err := mpiErr(C.MPI_Wait(&r.req, &st), "Wait"); err != nil {
+		return Status{}, err
+	}
+	return r.complete(&st)
+}
+
+// Test returns true, and the Status (see Wait), if the request has completed.
+func (r *Request) Test() (bool, Status, error) {
+	if r.done {
+		return true, r.status, nil
+	}
+	var flag C.int
+	var st C.MPI_Status
+	if err := mpiErr(C.MPI_Test(&r.req, &flag, &st), "Test"); err != nil {
+		return false, Status{}, err
+	}
+	if flag == 0 {
+		return false, Status{}, nil
+	}
+	s, err := r.complete(&st)
+	return true, s, err
+}
+
+// requests returns the MPI requests in reqs; completed requests are null
+func requests(reqs []*Request) []C.MPI_Request {
+	creqs := make([]C.MPI_Request, len(reqs))
+	for i, r := range reqs {
+		creqs[i] = r.req
+		if r.done {
+			creqs[i] = C.reqnull()
+		}
+	}
+	return creqs
+}
+
+// Waitall waits for all of reqs to complete, and returns their statuses.
+func Waitall(reqs []*Request) ([]Status, error) {
+	if len(reqs) == 0 {
+		return nil, nil
+	}
+	creqs := requests(reqs)
+	sts := make([]C.MPI_Status, len(reqs))
+	if err := mpiErr(C.MPI_Waitall(C.int(len(reqs)), &creqs[0], &sts[0]), "Waitall"); err != nil {
+		return nil, err
+	}
+	out := make([]Status, len(reqs))
+	for i, r := range reqs {
+		if r.done {
+			out[i] = r.status
+			continue
+		}
+		r.req = creqs[i]
+		var err error
+		if out[i], err = r.complete(&sts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Waitany waits for one of reqs to complete, and returns its index and status. Requests
+// that have already completed are ignored; if all of them have, the index is -1.
+func Waitany(reqs []*Request) (int, Status, error) {
+	creqs := requests(reqs)
+	active := false
+	for _, r := range creqs {
+		active = active || C.isnull(r) == 0
+	}
+	if !active {
+		return -1, Status{}, nil
+	}
+	var idx C.int
+	var st C.MPI_Status
+	if err := mpiErr(C.MPI_Waitany(C.int(len(reqs)), &creqs[0], &idx, &st), "Waitany"); err != nil {
+		return -1, Status{}, err
+	}
+	if idx == C.MPI_UNDEFINED {
+		return -1, Status{}, nil
+	}
+	r := reqs[idx]
+	r.req = creqs[idx]
+	s, err := r.complete(&st)
+	return int(idx), s, err
+}
+
+// Send sends buf to rank dest, blocking until buf can be reused.
+func Send[T Numeric](comm Comm, buf []T, dest, tag int) error {
+	t := C.MPI_Datatype(Datatype[T]())
+	return mpiErr(C.MPI_Send(ptr(buf), C.int(len(buf)), t, C.int(dest), C.int(tag), C.MPI_Comm(comm)), "Send")
+}
+
+// Recv receives a message of at most len(buf) elements from rank source (or AnySource)
+// with tag (or AnyTag) into buf. Use Probe to find the size of a message in advance.
+func Recv[T Numeric](comm Comm, buf []T, source, tag int) (Status, error) {
+	t := C.MPI_Datatype(Datatype[T]())
+	var st C.MPI_Status
+	perr := C.MPI_Recv(ptr(buf), C.int(len(buf)), t, C.int(source), C.int(tag), C.MPI_Comm(comm), &st)
+	if err := mpiErr(perr, "Recv"); err != nil {
+		return Status{}, err
+	}
+	return status(&st, t)
+}
+
+// Isend starts sending buf to rank dest, see Send.
+func Isend[T Numeric](comm Comm, buf []T, dest, tag int) (*Request, error) {
+	r := newRequest(buf, false)
+	perr := C.MPI_Isend(ptr(buf), C.int(len(buf)), r.t, C.int(dest), C.int(tag), C.MPI_Comm(comm), &r.req)
+	if err := mpiErr(perr, "Isend"); err != nil {
+		r.pin.Unpin()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Irecv starts receiving a message into buf, see Recv.
+func Irecv[T Numeric](comm Comm, buf []T, source, tag int) (*Request, error) {
+	r := newRequest(buf, true)
+	perr := C.MPI_Irecv(ptr(buf), C.int(len(buf)), r.t, C.int(source), C.int(tag), C.MPI_Comm(comm), &r.req)
+	if err := mpiErr(perr, "Irecv"); err != nil {
+		r.pin.Unpin()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Sendrecv sends send to rank dest while receiving a message from rank source into recv,
+// as for Send and Recv. send and recv must be different slices.
+func Sendrecv[T Numeric](comm Comm, send []T, dest, sendtag int, recv []T, source, recvtag int) (Status, error) {
+	if len(send) > 0 && len(recv) > 0 && unsafe.SliceData(send) == unsafe.SliceData(recv) {
+		return Status{}, fmt.Errorf("Sendrecv: send and recv must be different slices")
+	}
+	t := C.MPI_Datatype(Datatype[T]())
+	var st C.MPI_Status
+	perr := C.MPI_Sendrecv(ptr(send), C.int(len(send)), t, C.int(dest), C.int(sendtag),
+		ptr(recv), C.int(len(recv)), t, C.int(source), C.int(recvtag), C.MPI_Comm(comm), &st)
+	if err := mpiErr(perr, "Sendrecv"); err != nil {
+		return Status{}, err
+	}
+	return status(&st, t)
+}
+
+// Probe waits for a message from rank source (or AnySource) with tag (or AnyTag), without
+// receiving it. The Count is in elements of T, eg.
+//
+//	st, err := mpi.Probe[float64](comm, mpi.AnySource, tag)
+//	buf := make([]float64, st.Count)
+//	mpi.Recv(comm, buf, st.Source, st.Tag)
+func Probe[T Numeric](comm Comm, source, tag int) (Status, error) {
+	var st C.MPI_Status
+	if err := mpiErr(C.MPI_Probe(C.int(source), C.int(tag), C.MPI_Comm(comm), &st), "Probe"); err != nil {
+		return Status{}, err
+	}
+	return status(&st, C.MPI_Datatype(Datatype[T]()))
+}
+
+// Iprobe is Probe, without waiting; it returns false if there is no matching message.
+func Iprobe[T Numeric](comm Comm, source, tag int) (bool, Status, error) {
+	var flag C.int
+	var st C.MPI_Status
+	if err := mpiErr(C.MPI_Iprobe(C.int(source), C.int(tag), C.MPI_Comm(comm), &flag, &st), "Iprobe"); err != nil {
+		return false, Status{}, err
+	}
+	if flag == 0 {
+		return false, Status{}, nil
+	}
+	s, err := status(&st, C.MPI_Datatype(Datatype[T]()))
+	return true, s, err
+}
@@ -1,10 +1,28 @@
+// test-mpi runs tests of the mpi package; run it under mpirun with 2-4 processes,
+// eg. mpirun -np 3 test-mpi
 package main
 
 import (
-	"github.com/npadmana/npgo/mpi"
+	"fmt"
 	"log"
+
+	"github.com/npadmana/npgo/mpi"
 )
 
+var rank, size int
+
+// check reports the result of a test on this rank
+func check(name string, err error, ok bool, msg string, args ...interface{}) {
+	switch {
+	case err != nil:
+		log.Printf("FAIL : %s : rank %d : %v", name, rank, err)
+	case !ok:
+		log.Printf("FAIL : %s : rank %d : %s", name, rank, fmt.Sprintf(msg, args...))
+	case rank == 0:
+		log.Printf("PASS : %s", name)
+	}
+}
+
 func TestInt64() {
 	if n1 := mpi.TypeSize(mpi.MPI_i64); n1 != 8 {
 		log.Printf("FAIL : TestInt64 : Size of MPI_LONG=%d, needs to be 8 for code to work", n1)
@@ -13,8 +31,329 @@ func TestInt64() {
 	}
 }
 
+func TestAllReduceInt64() {
+	in := int64(rank + 1)
+	var out int64
+	mpi.AllReduceInt64(mpi.WORLD, &in, &out, 1, mpi.MAX)
+	check("TestAllReduceInt64", nil, out == int64(size), "MAX = %d", out)
+}
+
+func TestAllreduce() {
+	r := float64(rank + 1)
+	in := []float64{r, r, r}
+	out := make([]float64, 3)
+	err := mpi.Allreduce(mpi.WORLD, in, out, mpi.SUM)
+	n := float64(size)
+	check("TestAllreduce SUM", err, out[0] == n*(n+1)/2, "got %v", out)
+
+	err = mpi.Allreduce(mpi.WORLD, in, in, mpi.MAX)
+	check("TestAllreduce MAX in place", err, in[2] == n, "got %v", in)
+
+	f := []float32{float32(rank + 1)}
+	err = mpi.Allreduce(mpi.WORLD, f, f, mpi.MIN)
+	check("TestAllreduce MIN float32", err, f[0] == 1, "got %v", f)
+
+	p := []int64{int64(rank + 1)}
+	err = mpi.Allreduce(mpi.WORLD, p, p, mpi.PROD)
+	fact := int64(1)
+	for i := 2; i <= size; i++ {
+		fact *= int64(i)
+	}
+	check("TestAllreduce PROD int64", err, p[0] == fact, "got %v", p)
+
+	b := []int32{int32(rank), 1}
+	out32 := make([]int32, 2)
+	err = mpi.Allreduce(mpi.WORLD, b, out32, mpi.LAND)
+	check("TestAllreduce LAND", err, out32[0] == 0 && out32[1] == 1, "got %v", out32)
+	err = mpi.Allreduce(mpi.WORLD, b, out32, mpi.LOR)
+	check("TestAllreduce LOR", err, (out32[0] != 0) == (size > 1) && out32[1] == 1, "got %v", out32)
+
+	err = mpi.Allreduce(mpi.WORLD, in, in, mpi.LAND)
+	check("TestAllreduce LAND float64", nil, err != nil, "expected an error")
+	err = mpi.Allreduce(mpi.WORLD, in, out[:1], mpi.SUM)
+	check("TestAllreduce dimensions", nil, err != nil, "expected an error")
+}
+
+func TestReduce() {
+	in := []byte{byte(rank + 1)}
+	var out []byte
+	if rank == 0 {
+		out = make([]byte, 1)
+	}
+	err := mpi.Reduce(mpi.WORLD, in, out, mpi.MAX, 0)
+	check("TestReduce", err, rank != 0 || out[0] == byte(size), "got %v", out)
+
+	// In place on a root other than 0
+	root := size - 1
+	x := []float64{float64(rank), 1}
+	err = mpi.Reduce(mpi.WORLD, x, x, mpi.SUM, root)
+	n := float64(size)
+	check("TestReduce in place", err, rank != root || (x[0] == n*(n-1)/2 && x[1] == n), "got %v", x)
+
+	err = mpi.Allreduce(mpi.WORLD, in, in, mpi.SUM)
+	check("TestAllreduce SUM byte", err, int(in[0]) == size*(size+1)/2, "got %v", in)
+}
+
+func TestBcast() {
+	buf := make([]int32, 4)
+	root := size - 1
+	if rank == root {
+		for i := range buf {
+			buf[i] = int32(10 * i)
+		}
+	}
+	err := mpi.Bcast(mpi.WORLD, buf, root)
+	check("TestBcast", err, buf[3] == 30, "got %v", buf)
+}
+
+func TestGather() {
+	in := []float64{float64(rank), float64(-rank)}
+	out := make([]float64, 2*size)
+	err := mpi.Allgather(mpi.WORLD, in, out)
+	check("TestAllgather", err, out[2*size-2] == float64(size-1), "got %v", out)
+
+	err = mpi.Gather(mpi.WORLD, in, out, 0)
+	check("TestGather", err, rank != 0 || out[2*size-1] == float64(1-size), "got %v", out)
+
+	// Rank r sends r+1 copies of r
+	inv := make([]int64, rank+1)
+	for i := range inv {
+		inv[i] = int64(rank)
+	}
+	outv, counts, err := mpi.Gatherv(mpi.WORLD, inv, 0)
+	ok := true
+	if rank == 0 {
+		ok = len(outv) == size*(size+1)/2 && len(counts) == size
+		for i, j := 0, 0; ok && i < size; i++ {
+			ok = counts[i] == i+1
+			for k := 0; ok && k <= i; k, j = k+1, j+1 {
+				ok = outv[j] == int64(i)
+			}
+		}
+	} else {
+		ok = outv == nil && counts == nil
+	}
+	check("TestGatherv", err, ok, "got %v %v", outv, counts)
+}
+
+func TestScatter() {
+	var in []float32
+	if rank == 0 {
+		in = make([]float32, 2*size)
+		for i := range in {
+			in[i] = float32(i)
+		}
+	}
+	out := make([]float32, 2)
+	err := mpi.Scatter(mpi.WORLD, in, out, 0)
+	check("TestScatter", err, out[0] == float32(2*rank) && out[1] == float32(2*rank+1), "got %v", out)
+
+	// Rank i gets i+1 copies of i
+	var counts []int
+	in = nil
+	if rank == 0 {
+		counts = make([]int, size)
+		for i := range counts {
+			counts[i] = i + 1
+			for j := 0; j <= i; j++ {
+				in = append(in, float32(i))
+			}
+		}
+	}
+	out, err = mpi.Scatterv(mpi.WORLD, in, counts, 0)
+	ok := len(out) == rank+1
+	for i := 0; ok && i < len(out); i++ {
+		ok = out[i] == float32(rank)
+	}
+	check("TestScatterv", err, ok, "got %v", out)
+}
+
+func TestAlltoall() {
+	in := make([]int32, size)
+	out := make([]int32, size)
+	for i := range in {
+		in[i] = int32(100*rank + i)
+	}
+	err := mpi.Alltoall(mpi.WORLD, in, out)
+	ok := true
+	for i := 0; ok && i < size; i++ {
+		ok = out[i] == int32(100*i+rank)
+	}
+	check("TestAlltoall", err, ok, "got %v", out)
+
+	// Rank r sends (r+i)%3 copies of 100r+i to rank i
+	counts := make([]int, size)
+	var inv []int32
+	for i := range counts {
+		counts[i] = (rank + i) % 3
+		for j := 0; j < counts[i]; j++ {
+			inv = append(inv, int32(100*rank+i))
+		}
+	}
+	outv, rcounts, err := mpi.Alltoallv(mpi.WORLD, inv, counts)
+	ok = len(rcounts) == size
+	for i, j := 0, 0; ok && i < size; i++ {
+		ok = rcounts[i] == (rank+i)%3
+		for k := 0; ok && k < rcounts[i]; k, j = k+1, j+1 {
+			ok = outv[j] == int32(100*i+rank)
+		}
+	}
+	check("TestAlltoallv", err, ok, "got %v %v", outv, rcounts)
+}
+
+func TestScan() {
+	in := []int32{int32(rank + 1)}
+	out := make([]int32, 1)
+	err := mpi.Scan(mpi.WORLD, in, out, mpi.SUM)
+	check("TestScan", err, out[0] == int32((rank+1)*(rank+2)/2), "got %v", out)
+
+	err = mpi.Exscan(mpi.WORLD, in, out, mpi.SUM)
+	check("TestExscan", err, rank == 0 || out[0] == int32(rank*(rank+1)/2), "got %v", out)
+}
+
+// Point to point tests pass messages around a ring, or between pairs of ranks
+
+func TestSendRecv() {
+	partner := rank ^ 1
+	if partner >= size {
+		return
+	}
+	out := []float64{float64(rank), 1, 2}
+	in := make([]float64, 4)
+	var st mpi.Status
+	var err error
+	if rank%2 == 0 {
+		if err = mpi.Send(mpi.WORLD, out, partner, 3); err == nil {
+			st, err = mpi.Recv(mpi.WORLD, in, partner, 3)
+		}
+	} else {
+		if st, err = mpi.Recv(mpi.WORLD, in, mpi.AnySource, mpi.AnyTag); err == nil {
+			err = mpi.Send(mpi.WORLD, out, partner, 3)
+		}
+	}
+	ok := st.Source == partner && st.Tag == 3 && st.Count == 3 && in[0] == float64(partner) && in[2] == 2
+	check("TestSendRecv", err, ok, "got %v %+v", in, st)
+}
+
+func TestIsendIrecv() {
+	left, right := (rank+size-1)%size, (rank+1)%size
+	in := make([]int32, 2)
+	out := []int32{int32(rank), int32(10 * rank)}
+	rreq, err := mpi.Irecv(mpi.WORLD, in, left, 1)
+	if err != nil {
+		check("TestIsendIrecv", err, false, "")
+		return
+	}
+	sreq, err := mpi.Isend(mpi.WORLD, out, right, 1)
+	if err != nil {
+		check("TestIsendIrecv", err, false, "")
+		return
+	}
+	sts, err := mpi.Waitall([]*mpi.Request{rreq, sreq})
+	ok := err == nil && sts[0].Source == left && sts[0].Count == 2 && sts[1].Count == 2 && in[1] == int32(10*left)
+	check("TestIsendIrecv", err, ok, "got %v %+v", in, sts)
+
+	done, st, err := rreq.Test()
+	check("TestIsendIrecv Test", err, done && st == sts[0], "got %v %+v", done, st)
+}
+
+func TestWaitany() {
+	left, right := (rank+size-1)%size, (rank+1)%size
+	in := [][]float32{make([]float32, 1), make([]float32, 1)}
+	var reqs []*mpi.Request
+	for i, buf := range in {
+		r, err := mpi.Irecv(mpi.WORLD, buf, left, 10+i)
+		if err != nil {
+			check("TestWaitany", err, false, "")
+			return
+		}
+		reqs = append(reqs, r)
+	}
+	for i := len(in) - 1; i >= 0; i-- {
+		r, err := mpi.Isend(mpi.WORLD, []float32{float32(10*rank + i)}, right, 10+i)
+		if err == nil {
+			_, err = r.Wait()
+		}
+		if err != nil {
+			check("TestWaitany", err, false, "")
+			return
+		}
+	}
+	seen := make(map[int]bool)
+	ok := true
+	for range in {
+		i, st, err := mpi.Waitany(reqs)
+		if err != nil {
+			check("TestWaitany", err, false, "")
+			return
+		}
+		ok = ok && i >= 0 && !seen[i] && st.Tag == 10+i && in[i][0] == float32(10*left+i)
+		seen[i] = true
+	}
+	i, _, err := mpi.Waitany(reqs)
+	check("TestWaitany", err, ok && i == -1, "got %v, last index %d", in, i)
+}
+
+func TestProbe() {
+	left, right := (rank+size-1)%size, (rank+1)%size
+	out := make([]int64, rank+1)
+	for i := range out {
+		out[i] = int64(rank)
+	}
+	sreq, err := mpi.Isend(mpi.WORLD, out, right, 7)
+	if err != nil {
+		check("TestProbe", err, false, "")
+		return
+	}
+	st, err := mpi.Probe[int64](mpi.WORLD, mpi.AnySource, 7)
+	if err != nil {
+		check("TestProbe", err, false, "")
+		return
+	}
+	in := make([]int64, st.Count)
+	st1, err := mpi.Recv(mpi.WORLD, in, st.Source, st.Tag)
+	if err == nil {
+		_, err = sreq.Wait()
+	}
+	ok := st.Source == left && st.Count == left+1 && st1 == st && in[left] == int64(left)
+	check("TestProbe", err, ok, "got %v %+v", in, st)
+
+	found, _, err := mpi.Iprobe[int64](mpi.WORLD, mpi.AnySource, 7)
+	check("TestIprobe", err, !found, "found an unexpected message")
+}
+
+func TestSendrecv() {
+	left, right := (rank+size-1)%size, (rank+1)%size
+	in := make([]byte, 1)
+	st, err := mpi.Sendrecv(mpi.WORLD, []byte{byte(rank)}, right, 5, in, left, 5)
+	check("TestSendrecv", err, st.Source == left && st.Count == 1 && in[0] == byte(left), "got %v %+v", in, st)
+
+	_, err = mpi.Sendrecv(mpi.WORLD, in, right, 5, in, left, 5)
+	check("TestSendrecv same slice", nil, err != nil, "expected an error")
+}
+
 func main() {
 	mpi.Initialize()
+	var err error
+	if rank, err = mpi.Rank(mpi.WORLD); err != nil {
+		log.Fatal(err)
+	}
+	if size, err = mpi.Size(mpi.WORLD); err != nil {
+		log.Fatal(err)
+	}
 	TestInt64()
+	TestAllReduceInt64()
+	TestAllreduce()
+	TestReduce()
+	TestBcast()
+	TestGather()
+	TestScatter()
+	TestAlltoall()
+	TestScan()
+	TestSendRecv()
+	TestIsendIrecv()
+	TestWaitany()
+	TestProbe()
+	TestSendrecv()
 	mpi.Finalize()
 }
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/npadmana/npgo/gadget"
+	"github.com/npadmana/npgo/petsc"
+	"github.com/npadmana/npgo/petsc/particles"
+	"github.com/npadmana/npgo/petsc/particles/PW3D"
+)
+
+func main() {
+	// PETSc initialization
+	if err := petsc.Initialize(); err != nil {
+		petsc.Fatal(err)
+	}
+	defer func() {
+		if err := petsc.Finalize(); err != nil {
+			petsc.Fatal(err)
+		}
+	}()
+	rank, size := petsc.RankSize()
+
+	var base string
+	var ptype int
+	flag.StringVar(&base, "snap", "", "Gadget snapshot, eg. snap_005 for snap_005.0, snap_005.1 ...")
+	flag.IntVar(&ptype, "type", -1, "Particle type to read, -1 for all")
+	flag.Parse()
+	if base == "" {
+		petsc.Printf("Need to specify a snapshot\n")
+		return
+	}
+	mask := uint(gadget.AllTypes)
+	if ptype >= 0 {
+		mask = 1 << uint(ptype)
+	}
+
+	// Read in the header, to get the box size
+	fns, hdr, err := gadget.Files(base)
+	if err != nil {
+		petsc.Fatal(err)
+	}
+	L := float32(hdr.BoxSize)
+
+	slab := particles.Slab{L: L, N: size, Idim: 0}
+	pp, hdr := PW3D.ReadGadget(base, mask, slab)
+	defer pp.Destroy()
+	petsc.Printf("Read %d particles from %d files, z = %f\n", pp.Ntotal, len(fns), hdr.Redshift)
+
+	lpp := PW3D.GetArray(pp)
+	petsc.SyncPrintf("# Rank %d has %d particles....\n", rank, lpp.Length())
+	petsc.SyncFlush()
+	pp.RestoreArray()
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"log"
+
+	"github.com/npadmana/npgo/gnuplot"
+	"github.com/npadmana/npgo/mpi"
 	"github.com/npadmana/npgo/petsc"
 	"github.com/npadmana/npgo/petsc/particles"
 	"github.com/npadmana/npgo/petsc/particles/PW3D"
@@ -42,4 +46,21 @@ func main() {
 	petsc.SyncFlush()
 	pp.RestoreArray()
 
+	// Plot a subsample of the particles, projected onto x-y
+	lpp = PW3D.GetArray(pp)
+	sample, err := gnuplot.ParticleSample(lpp, 0.05, []int{0, 1}, nil, mpi.Gatherer{Comm: petsc.WORLD})
+	pp.RestoreArray()
+	if err != nil {
+		petsc.Fatal(err)
+	}
+	if sample != nil {
+		fig := gnuplot.NewFigure(1, 1)
+		ax := fig.Axes(0, 0)
+		ax.X.Label, ax.Y.Label = "x", "y"
+		ax.Add(sample)
+		if err = fig.Save("slab.png"); err != nil {
+			log.Println("Error plotting particles :", err)
+		}
+	}
+
 }
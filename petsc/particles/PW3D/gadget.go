@@ -0,0 +1,62 @@
+package PW3D
+
+import (
+	"fmt"
+
+	"github.com/npadmana/npgo/gadget"
+	"github.com/npadmana/npgo/petsc"
+	"github.com/npadmana/npgo/petsc/particles"
+	"github.com/npadmana/npgo/petsc/structvec"
+)
+
+// gadgetChunk is the number of particles read at a time
+const gadgetChunk = 65536
+
+// ReadGadget reads the particles of the types in mask (see gadget.AllTypes) from the
+// Gadget snapshot base into a new vector, with W set to the particle mass. It also returns
+// the header of the first file in the snapshot.
+//
+// Each MPI rank reads a disjoint subset of the files, and the particles are then
+// distributed with DomainDecompose. If d is nil, particles stay on the rank that read them.
+//
+// This must be called on all ranks. Errors call petsc.Fatal.
+func ReadGadget(base string, mask uint, d particles.Domainer) (*structvec.StructVec, gadget.Header) {
+	fns, hdr, err := gadget.Files(base)
+	if err != nil {
+		petsc.Fatal(err)
+	}
+
+	rank, size := petsc.RankSize()
+	snap, err := gadget.OpenFiles(gadget.Subset(fns, rank, size))
+	if err != nil {
+		petsc.Fatal(err)
+	}
+	defer snap.Close()
+	nlocal := snap.Count(mask)
+
+	s := NewVec(nlocal, petsc.DETERMINE)
+	pp := GetArray(s)
+	var i int
+	err = snap.Read(mask, gadgetChunk, func(p []gadget.Particle) error {
+		if i+len(p) > len(pp) {
+			return fmt.Errorf("More particles than expected : %d", nlocal)
+		}
+		for _, p1 := range p {
+			pp[i] = One{Pos: p1.Pos, W: p1.Mass}
+			i++
+		}
+		return nil
+	})
+	if err == nil && i != len(pp) {
+		err = fmt.Errorf("Read %d particles, expected %d", i, len(pp))
+	}
+	if err != nil {
+		petsc.Fatal(err)
+	}
+	s.RestoreArray()
+
+	if d != nil {
+		DomainDecompose(d, s)
+	}
+	return s, hdr
+}
@@ -11,13 +11,15 @@ func main() {
 		log.Fatal(err)
 	}
 
-	pp <- "set term png"
-	pp <- "set output \"sin.png\""
-	pp <- "plot sin(x)"
-	pp <- "set term pdfcairo"
-	pp <- "set output \"cos.pdf\""
-	pp <- "plot cos(x) lw 5"
-	pp <- "set output"
-	close(pp)
+	pp.Cmd("set term png")
+	pp.Cmd("set output \"sin.png\"")
+	pp.Cmd("plot sin(x)")
+	pp.Cmd("set term pdfcairo")
+	pp.Cmd("set output \"cos.pdf\"")
+	pp.Cmd("plot cos(x) lw 5")
+	pp.Cmd("set output")
+	if err = pp.Close(); err != nil {
+		log.Fatal(err)
+	}
 
 }
@@ -21,6 +21,15 @@ const (
 
 type Pos [3]float64
 
+// Output columns for xyzwi files
+var xyzwiCols = []lineio.Column{
+	{Name: "x", Width: 10, Precision: 4},
+	{Name: "y", Width: 10, Precision: 4},
+	{Name: "z", Width: 10, Precision: 4},
+	{Name: "w", Width: 7, Precision: 4},
+	{Name: "i", Type: lineio.IntColumn, Width: 8},
+}
+
 var (
 	loPos = Pos{-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
 	hiPos = Pos{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
@@ -90,27 +99,24 @@ func readWeight(wfn string, Pk float64) (*spline.Spline, error) {
 	}
 
 	// Test the spline
-	plot, err := gnuplot.New(false)
-	defer close(plot)
-	if err != nil {
-		return nil, err
-	}
-	plot <- "set term pngcairo"
-	plot <- "set output 'fkp_test.png'"
-	plot <- "plot '-' w points ps 3, '-' w lines lw 2"
-	for i := range wstr.zz {
-		plot <- fmt.Sprintln(wstr.zz[i], wstr.fkp[i])
-	}
-	plot <- "e"
-	var nz float64
+	var zz, nz []float64
+	var nz1 float64
 	for z1 := wstr.zz[0]; z1 < wstr.zz[len(wstr.zz)-1]; z1 = z1 + 0.001 {
-		if nz, err = sp.Eval(z1); err != nil {
+		if nz1, err = sp.Eval(z1); err != nil {
 			return nil, err
 		}
-		plot <- fmt.Sprint(z1, nz)
+		zz = append(zz, z1)
+		nz = append(nz, nz1)
+	}
+	fig := gnuplot.NewFigure(1, 1)
+	ax := fig.Axes(0, 0)
+	ax.X.Label = "z"
+	ax.Y.Label = "FKP weight"
+	ax.Add(&gnuplot.Series{X: wstr.zz, Y: wstr.fkp, Title: "data", Style: gnuplot.Points, PointSize: 3})
+	ax.Add(&gnuplot.Series{X: zz, Y: nz, Title: "spline", LineWidth: 2})
+	if err = fig.Save("fkp_test.png"); err != nil {
+		log.Println("Error plotting the weights :", err)
 	}
-	plot <- "e"
-	plot <- "set output"
 
 	// Return the spline
 	return sp, nil
@@ -147,11 +153,11 @@ func doOne(infn, outfn string, zmin, zmax float64, dist, fkp *spline.Spline, min
 		return err
 	}
 
-	gg, err := os.Create(outfn)
+	gg, err := lineio.Create(outfn, xyzwiCols)
 	if err != nil {
 		return err
 	}
-	defer gg.Close()
+	defer gg.Abort()
 
 	ind := 1
 	maxz := -1.0
@@ -179,8 +185,12 @@ func doOne(infn, outfn string, zmin, zmax float64, dist, fkp *spline.Spline, min
 		p[0] = r * math.Sin(theta) * math.Cos(phi)
 		p[1] = r * math.Sin(theta) * math.Sin(phi)
 		p[2] = r * math.Cos(theta)
-		_, err = fmt.Fprintf(gg, "%10.4f %10.4f %10.4f %7.4f %8d\n", p[0], p[1], p[2], arr[ii].w, ind)
-		if err != nil {
+		gg.Float(p[0])
+		gg.Float(p[1])
+		gg.Float(p[2])
+		gg.Float(arr[ii].w)
+		gg.Int(int64(ind))
+		if err = gg.EndRow(); err != nil {
 			panic("Error while writing file")
 		}
 		minpos.Min(p)
@@ -188,6 +198,9 @@ func doOne(infn, outfn string, zmin, zmax float64, dist, fkp *spline.Spline, min
 		ind++
 	}
 
+	if err = gg.Close(); err != nil {
+		return err
+	}
 	fmt.Printf("%s had zmin, zmax = %f, %f, %d objects removed \n", infn, minz, maxz, elim)
 	return nil
 
@@ -2,9 +2,9 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
-	"os"
 	"sync"
 	"time"
 
@@ -26,19 +26,37 @@ func (arr *RDZWArr) Add(s []byte) error {
 	return nil
 }
 
+func (arr *RDZWArr) Empty() lineio.ParallelLineIOType {
+	return new(RDZWArr)
+}
+
+func (arr *RDZWArr) Merge(a lineio.ParallelLineIOType) error {
+	*arr = append(*arr, *(a.(*RDZWArr))...)
+	return nil
+}
+
 func main() {
+	nchunk := flag.Int("nchunk", 1, "Number of chunks to parse each file in")
+	flag.Parse()
+
 	t := time.Now()
 	var wg sync.WaitGroup
-	for i := 1; i < len(os.Args); i++ {
+	for _, fn := range flag.Args() {
 		wg.Add(1)
 		go func(fn string) {
 			var l RDZWArr
-			if err := lineio.Read(fn, &l); err != nil {
+			var err error
+			if *nchunk > 1 {
+				err = lineio.ReadParallel(fn, *nchunk, &l)
+			} else {
+				err = lineio.Read(fn, &l)
+			}
+			if err != nil {
 				log.Fatal(err)
 			}
 			fmt.Printf("%s has %d records\n", fn, len(l))
 			wg.Done()
-		}(os.Args[i])
+		}(fn)
 	}
 	wg.Wait()
 	fmt.Printf("Elapsed time : %s\n", time.Since(t))